@@ -0,0 +1,78 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package fs
+
+import (
+	iofs "io/fs"
+	"path/filepath"
+)
+
+// SkipFunc checks if the given path of a walked file tree is skipped, including all paths inside of it when it is a
+// directory.
+// The name is the slash-separated path relative to the root directory of the walk. The function is called in lexical
+// order for all paths except the root directory itself and is never called for paths inside of skipped directories.
+type SkipFunc func(name string, d iofs.DirEntry) (bool, error)
+
+// WalkOption is a walk option.
+type WalkOption func(*WalkOptions)
+
+// WalkOptions are walk options.
+type WalkOptions struct {
+	// SkipFuncs are the functions that decide whether paths are skipped where a path is skipped as soon as one of them
+	// skips it.
+	SkipFuncs []SkipFunc
+}
+
+// NewWalkOptions creates new walk options.
+func NewWalkOptions(opts ...WalkOption) *WalkOptions {
+	opt := &WalkOptions{}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	return opt
+}
+
+// WithSkipFuncs adds functions that decide whether paths are skipped.
+func WithSkipFuncs(fns ...SkipFunc) WalkOption {
+	return func(o *WalkOptions) {
+		o.SkipFuncs = append(o.SkipFuncs, fns...)
+	}
+}
+
+// Walk walks the file tree rooted at the given root directory like the "filepath.WalkDir" function, but skips all paths
+// that are skipped by at least one of the functions set through the SkipFuncs option, e.g. paths that are ignored by a
+// version control system.
+// The given function is never called for skipped paths and paths inside of skipped directories.
+func Walk(root string, fn iofs.WalkDirFunc, opts ...WalkOption) error {
+	opt := NewWalkOptions(opts...)
+
+	return filepath.WalkDir(root, func(p string, d iofs.DirEntry, err error) error {
+		if err != nil || len(opt.SkipFuncs) == 0 {
+			return fn(p, d, err)
+		}
+		rel, relErr := filepath.Rel(root, p)
+		if relErr != nil {
+			return relErr
+		}
+		if rel == "." {
+			return fn(p, d, nil)
+		}
+
+		for _, skip := range opt.SkipFuncs {
+			skipped, skipErr := skip(filepath.ToSlash(rel), d)
+			if skipErr != nil {
+				return skipErr
+			}
+			if skipped {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		return fn(p, d, nil)
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package fs_test
+
+import (
+	"errors"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/svengreb/golib/pkg/io/fs"
+)
+
+// writeFiles writes the given files by their slash-separated path relative to the given root directory.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			assert.FailNow(t, "failed to create directory", "file: %q\nerror: %v", name, err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			assert.FailNow(t, "failed to write file", "file: %q\nerror: %v", name, err)
+		}
+	}
+}
+
+// walkFiles walks the given root directory and returns the slash-separated paths of all visited files.
+func walkFiles(t *testing.T, root string, opts ...fs.WalkOption) []string {
+	t.Helper()
+	var files []string
+	err := fs.Walk(root, func(p string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, relErr := filepath.Rel(root, p)
+			if relErr != nil {
+				return relErr
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	}, opts...)
+	if err != nil {
+		assert.FailNow(t, "failed to walk directory", "root: %q\nerror: %v", root, err)
+	}
+
+	return files
+}
+
+func TestWalk(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":         "",
+		"main.go.swp":     "",
+		"build/out":       "",
+		"pkg/a/a.go":      "",
+		"pkg/build/out":   "",
+		"vendor/b/b.go":   "",
+		"vendor/b/README": "",
+	})
+
+	assert.Equal(t, []string{
+		"build/out", "main.go", "main.go.swp", "pkg/a/a.go", "pkg/build/out", "vendor/b/README", "vendor/b/b.go",
+	}, walkFiles(t, root))
+
+	var visited []string
+	skipSwap := func(name string, d iofs.DirEntry) (bool, error) {
+		visited = append(visited, name)
+		return strings.HasSuffix(name, ".swp"), nil
+	}
+	skipDirs := func(name string, d iofs.DirEntry) (bool, error) {
+		return d.IsDir() && (name == "build" || name == "vendor"), nil
+	}
+	assert.Equal(t, []string{"main.go", "pkg/a/a.go", "pkg/build/out"},
+		walkFiles(t, root, fs.WithSkipFuncs(skipSwap), fs.WithSkipFuncs(skipDirs)))
+	// Paths inside of skipped directories are never passed to the skip functions.
+	assert.NotContains(t, visited, "build/out")
+	assert.NotContains(t, visited, "vendor/b")
+	assert.NotContains(t, visited, ".")
+
+	errSkip := errors.New("skip error")
+	err := fs.Walk(root, func(string, iofs.DirEntry, error) error { return nil },
+		fs.WithSkipFuncs(func(string, iofs.DirEntry) (bool, error) { return false, errSkip }))
+	assert.True(t, errors.Is(err, errSkip))
+}
@@ -0,0 +1,663 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+
+	"github.com/svengreb/golib/pkg/vcs/git/attributes"
+)
+
+// infoAttributesPath is the path of the repository specific attributes file relative to the Git directory.
+var infoAttributesPath = path.Join("info", "attributes")
+
+const (
+	// archiveDirMode is the normalized permission of directories in archives.
+	archiveDirMode = 0o755
+
+	// archiveExecutableMode is the normalized permission of executable files in archives.
+	archiveExecutableMode = 0o755
+
+	// archiveFileMode is the normalized permission of regular files in archives.
+	archiveFileMode = 0o644
+
+	// archiveSymlinkMode is the normalized permission of symbolic links in archives.
+	archiveSymlinkMode = 0o777
+)
+
+// ArchiveFormat is the format of an archive.
+type ArchiveFormat int
+
+const (
+	// ArchiveTar is the uncompressed tar archive format.
+	ArchiveTar ArchiveFormat = iota
+
+	// ArchiveTarGz is the gzip compressed tar archive format.
+	ArchiveTarGz
+
+	// ArchiveZip is the zip archive format with deflate compressed files.
+	ArchiveZip
+)
+
+// ParseArchiveFormat parses the given name of an archive format.
+// Supported are the names of the "--format" flag of the Git "archive" command, which are "tar", "tar.gz", "tgz" and
+// "zip".
+func ParseArchiveFormat(name string) (ArchiveFormat, error) {
+	switch strings.ToLower(name) {
+	case "tar":
+		return ArchiveTar, nil
+	case "tar.gz", "tgz":
+		return ArchiveTarGz, nil
+	case "zip":
+		return ArchiveZip, nil
+	default:
+		return ArchiveTar, newError(ErrInvalidOption, nil, "unsupported archive format %q", name)
+	}
+}
+
+// String returns the name of the archive format, which is also the common file extension for archives of the format.
+func (f ArchiveFormat) String() string {
+	switch f {
+	case ArchiveTar:
+		return "tar"
+	case ArchiveTarGz:
+		return "tar.gz"
+	case ArchiveZip:
+		return "zip"
+	default:
+		return fmt.Sprintf("ArchiveFormat(%d)", int(f))
+	}
+}
+
+// ArchiveOptions are options to export an archive.
+type ArchiveOptions struct {
+	// Format is the format of the archive.
+	// This is the equivalent of the "--format" flag of the Git "archive" command.
+	Format ArchiveFormat
+
+	// ModTime is the modification time of all files and directories of the archive.
+	// The committer time of the commit is used when it is the zero time, or the Unix epoch when a tree is exported.
+	ModTime time.Time
+
+	// Prefix is the slash-separated path of the directory all files and directories of the archive are placed in, like
+	// "golib-1.0.0" to extract them into a directory named after the release.
+	// Unlike the "--prefix" flag of the Git "archive" command, the prefix is always a directory and must be relative
+	// without any parent directory references.
+	Prefix string
+}
+
+// archiveEntry is a file or directory of an archive.
+type archiveEntry struct {
+	// content is the content of a regular or executable file.
+	content io.Reader
+
+	// linkname is the target of a symbolic link.
+	linkname string
+
+	// mode is the mode of the entry in the tree.
+	mode filemode.FileMode
+
+	// name is the slash-separated path of the entry including the prefix, with a trailing slash for directories.
+	name string
+
+	// size is the size of the content in bytes.
+	size int64
+}
+
+// archiveWriter writes the entries of an archive in a specific format.
+type archiveWriter interface {
+	// Close writes the remaining data of the archive, but does not close the underlying writer.
+	Close() error
+
+	// writeEntry writes the given entry.
+	writeEntry(entry *archiveEntry) error
+}
+
+// archiver exports a tree as archive.
+type archiver struct {
+	// attrs are the attributes of the exported tree.
+	attrs *attributes.Matcher
+
+	// commit is the commit of the exported tree, or nil when a tree is exported directly.
+	commit *object.Commit
+
+	// w is the writer of the archive format.
+	w archiveWriter
+}
+
+// Archive exports the tree of the given tree-ish of a Git repository as archive to the given writer.
+// This is the equivalent of the "git archive" command, but the output is fully reproducible so that identical trees
+// always result in byte-identical archives with stable checksums.
+//
+// The tree-ish can be any revision supported by the Git "rev-parse" command, like a branch, tag or commit hash, or the
+// full hash of a tree, and defaults to HEAD when empty.
+// Like the Git "archive" command, the attributes files of the exported tree and the "info/attributes" file of the Git
+// directory, which takes precedence, are taken into account: paths with the "export-ignore" attribute are skipped and
+// "$Format:...$" placeholders in files with the "export-subst" attribute are expanded for the exported commit. Like
+// Git, the placeholders are expanded in all files with the attribute, including binary files, so the attribute should
+// only be set for text files. Supported are the placeholders of the "--format" flag of the Git "log" command for
+// hashes, authors, committers, dates and messages while the short commit hashes are always shortened to the
+// DefaultHashAbbrevLength. Unsupported placeholders, like references that can change for the same commit, are kept as
+// they are.
+//
+// Files and directories are written in the order of the tree with the modification time set through the ModTime
+// option and normalized permissions of 0644 for regular files and 0755 for executable files and directories, owned by
+// the "root" user and group. Submodules are written as empty directories.
+// All options are the default values when the given archive options are nil. The DetectDotGit and CeilingDirs options
+// are used to find the repository while all other options are ignored.
+func Archive(repositoryPath, treeish string, w io.Writer, archiveOpts *ArchiveOptions, opts ...Option) error {
+	repo, repoOpenErr := openRepository(repositoryPath, NewOptions(opts...))
+	if repoOpenErr != nil {
+		return repoOpenErr
+	}
+
+	return ArchiveFromRepository(repo, treeish, w, archiveOpts)
+}
+
+// ArchiveFromRepository is like Archive but exports the tree of the given already opened repository.
+func ArchiveFromRepository(repo *git.Repository, treeish string, w io.Writer, archiveOpts *ArchiveOptions) error {
+	if repo == nil {
+		return newError(ErrRepositoryNotFound, nil, "repository must not be nil")
+	}
+	if archiveOpts == nil {
+		archiveOpts = &ArchiveOptions{}
+	}
+	prefix, prefixErr := archivePrefix(archiveOpts.Prefix)
+	if prefixErr != nil {
+		return prefixErr
+	}
+
+	tree, commit, resolveErr := resolveTreeish(repo, treeish)
+	if resolveErr != nil {
+		return resolveErr
+	}
+	info, infoErr := readInfoAttributes(repo)
+	if infoErr != nil {
+		return infoErr
+	}
+	attrs, attrsErr := attributes.LoadTree(tree, info...)
+	if attrsErr != nil {
+		return attrsErr
+	}
+
+	modTime := archiveOpts.ModTime
+	switch {
+	case !modTime.IsZero():
+	case commit != nil:
+		modTime = commit.Committer.When
+	default:
+		modTime = time.Unix(0, 0)
+	}
+	// Archive formats only support a resolution of seconds while time zones would change the output of the zip format.
+	modTime = modTime.Truncate(time.Second).UTC()
+
+	var comment string
+	if commit != nil {
+		comment = commit.Hash.String()
+	}
+	var aw archiveWriter
+	switch archiveOpts.Format {
+	case ArchiveTar:
+		aw = newTarArchiveWriter(w, nil, modTime, comment)
+	case ArchiveTarGz:
+		gw, gzipErr := gzip.NewWriterLevel(w, gzip.DefaultCompression)
+		if gzipErr != nil {
+			return fmt.Errorf("failed to create gzip writer: %w", gzipErr)
+		}
+		aw = newTarArchiveWriter(gw, gw, modTime, comment)
+	case ArchiveZip:
+		aw = newZipArchiveWriter(w, modTime, comment)
+	default:
+		return newError(ErrInvalidOption, nil, "unsupported archive format %s", archiveOpts.Format)
+	}
+
+	a := &archiver{attrs: attrs, commit: commit, w: aw}
+	if prefix != "" {
+		if writeErr := aw.writeEntry(&archiveEntry{mode: filemode.Dir, name: prefix}); writeErr != nil {
+			return writeErr
+		}
+	}
+	if writeErr := a.writeTree(tree, "", prefix); writeErr != nil {
+		return writeErr
+	}
+	if closeErr := aw.Close(); closeErr != nil {
+		return fmt.Errorf("failed to write %s archive: %w", archiveOpts.Format, closeErr)
+	}
+
+	return nil
+}
+
+// archivePrefix validates the given archive prefix and returns it with a trailing slash, or an empty string when
+// there is no prefix.
+func archivePrefix(prefix string) (string, error) {
+	if prefix == "" {
+		return "", nil
+	}
+	cleaned := path.Clean(prefix)
+	if cleaned == "." || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", newError(ErrInvalidOption, nil, "invalid archive prefix %q", prefix)
+	}
+
+	return cleaned + "/", nil
+}
+
+// readInfoAttributes reads the "info/attributes" file of the Git directory of the given repository.
+// No file is returned when the repository is not stored in a filesystem or the file does not exist.
+func readInfoAttributes(repo *git.Repository) ([]*attributes.File, error) {
+	fsStorage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil, nil
+	}
+
+	file, openErr := fsStorage.Filesystem().Open(infoAttributesPath)
+	if errors.Is(openErr, os.ErrNotExist) {
+		return nil, nil
+	}
+	if openErr != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", infoAttributesPath, openErr)
+	}
+	defer func() { _ = file.Close() }()
+
+	info, parseErr := attributes.ParseInfo(file)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	return []*attributes.File{info}, nil
+}
+
+// resolveTreeish resolves the given tree-ish to a tree and, unless the tree-ish is the hash of a tree, the commit of
+// the tree.
+func resolveTreeish(repo *git.Repository, treeish string) (*object.Tree, *object.Commit, error) {
+	if plumbing.IsHash(treeish) {
+		if tree, treeErr := repo.TreeObject(plumbing.NewHash(treeish)); treeErr == nil {
+			return tree, nil, nil
+		}
+	}
+
+	hash, resolveErr := resolveRevision(repo, treeish)
+	if resolveErr != nil {
+		return nil, nil, resolveErr
+	}
+	commit, commitErr := repo.CommitObject(hash)
+	if commitErr != nil {
+		return nil, nil, fmt.Errorf("failed to get commit %s: %w", hash, commitErr)
+	}
+	tree, treeErr := commit.Tree()
+	if treeErr != nil {
+		return nil, nil, fmt.Errorf("failed to get tree of commit %s: %w", hash, treeErr)
+	}
+
+	return tree, commit, nil
+}
+
+// writeTree writes the entries of the given tree with the given slash-separated path relative to the root tree, or
+// an empty string for the root tree itself, recursively in the order of the tree.
+func (a *archiver) writeTree(tree *object.Tree, dir, prefix string) error {
+	for i := range tree.Entries {
+		entry := &tree.Entries[i]
+		name := path.Join(dir, entry.Name)
+		isDir := entry.Mode == filemode.Dir || entry.Mode == filemode.Submodule
+		attrs := a.attrs.Match(name, isDir)
+		if attrs.IsSet(attributes.ExportIgnore) {
+			continue
+		}
+
+		switch entry.Mode {
+		case filemode.Dir:
+			if writeErr := a.w.writeEntry(&archiveEntry{mode: filemode.Dir, name: prefix + name + "/"}); writeErr != nil {
+				return writeErr
+			}
+			subtree, subtreeErr := tree.Tree(entry.Name)
+			if subtreeErr != nil {
+				return fmt.Errorf("failed to get tree %q: %w", name, subtreeErr)
+			}
+			if writeErr := a.writeTree(subtree, name, prefix); writeErr != nil {
+				return writeErr
+			}
+
+		case filemode.Submodule:
+			if writeErr := a.w.writeEntry(&archiveEntry{mode: filemode.Dir, name: prefix + name + "/"}); writeErr != nil {
+				return writeErr
+			}
+
+		default:
+			if writeErr := a.writeFile(tree, entry, name, prefix, attrs.IsSet(attributes.ExportSubst)); writeErr != nil {
+				return writeErr
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeFile writes the file, executable file or symbolic link of the given tree entry with the given slash-separated
+// path relative to the root tree.
+// The target of symbolic links is read here once for all archive formats while the placeholders of regular and
+// executable files are expanded when subst is true and a commit is exported.
+func (a *archiver) writeFile(tree *object.Tree, entry *object.TreeEntry, name, prefix string, subst bool) error {
+	file, fileErr := tree.TreeEntryFile(entry)
+	if fileErr != nil {
+		return fmt.Errorf("failed to get file %q: %w", name, fileErr)
+	}
+	r, readerErr := file.Reader()
+	if readerErr != nil {
+		return fmt.Errorf("failed to read file %q: %w", name, readerErr)
+	}
+	defer func() { _ = r.Close() }()
+
+	archived := &archiveEntry{mode: entry.Mode, name: prefix + name}
+	switch {
+	case entry.Mode == filemode.Symlink:
+		target, readErr := io.ReadAll(r)
+		if readErr != nil {
+			return fmt.Errorf("failed to read symbolic link %q: %w", name, readErr)
+		}
+		archived.linkname = string(target)
+	case subst && a.commit != nil:
+		content, readErr := io.ReadAll(r)
+		if readErr != nil {
+			return fmt.Errorf("failed to read file %q: %w", name, readErr)
+		}
+		content = expandFormatPlaceholders(content, a.commit)
+		archived.content, archived.size = bytes.NewReader(content), int64(len(content))
+	default:
+		archived.content, archived.size = r, file.Size
+	}
+
+	return a.w.writeEntry(archived)
+}
+
+// tarArchiveWriter writes archives in the tar format.
+type tarArchiveWriter struct {
+	// comment is the comment that is written as global header, or an empty string to write no global header.
+	comment string
+
+	// compressor is the writer that compresses the archive, or nil if the archive is not compressed.
+	compressor io.WriteCloser
+
+	// modTime is the modification time of all entries.
+	modTime time.Time
+
+	// tw is the tar writer.
+	tw *tar.Writer
+}
+
+// newTarArchiveWriter creates a new tar archive writer that writes to the given writer.
+// The given compressor is closed after the archive has been written, unless nil.
+func newTarArchiveWriter(w io.Writer, compressor io.WriteCloser, modTime time.Time, comment string) *tarArchiveWriter {
+	return &tarArchiveWriter{comment: comment, compressor: compressor, modTime: modTime, tw: tar.NewWriter(w)}
+}
+
+func (aw *tarArchiveWriter) Close() error {
+	if closeErr := aw.writeGlobalHeader(); closeErr != nil {
+		return closeErr
+	}
+	if closeErr := aw.tw.Close(); closeErr != nil {
+		return closeErr
+	}
+	if aw.compressor != nil {
+		return aw.compressor.Close()
+	}
+
+	return nil
+}
+
+func (aw *tarArchiveWriter) writeEntry(entry *archiveEntry) error {
+	if headerErr := aw.writeGlobalHeader(); headerErr != nil {
+		return headerErr
+	}
+
+	hdr := &tar.Header{Gname: "root", ModTime: aw.modTime, Name: entry.name, Uname: "root"}
+	switch entry.mode {
+	case filemode.Dir:
+		hdr.Mode, hdr.Typeflag = archiveDirMode, tar.TypeDir
+	case filemode.Symlink:
+		hdr.Linkname, hdr.Mode, hdr.Typeflag = entry.linkname, archiveSymlinkMode, tar.TypeSymlink
+	case filemode.Executable:
+		hdr.Mode, hdr.Size, hdr.Typeflag = archiveExecutableMode, entry.size, tar.TypeReg
+	default:
+		hdr.Mode, hdr.Size, hdr.Typeflag = archiveFileMode, entry.size, tar.TypeReg
+	}
+
+	if headerErr := aw.tw.WriteHeader(hdr); headerErr != nil {
+		return fmt.Errorf("failed to write tar header of %q: %w", entry.name, headerErr)
+	}
+	if hdr.Typeflag == tar.TypeReg {
+		if _, copyErr := io.Copy(aw.tw, entry.content); copyErr != nil {
+			return fmt.Errorf("failed to write %q to tar archive: %w", entry.name, copyErr)
+		}
+	}
+
+	return nil
+}
+
+// writeGlobalHeader writes the comment as global header once before the first entry, like the Git "archive" command
+// does to store the hash of the exported commit that can be read through the "git get-tar-commit-id" command.
+func (aw *tarArchiveWriter) writeGlobalHeader() error {
+	if aw.comment == "" {
+		return nil
+	}
+	hdr := &tar.Header{PAXRecords: map[string]string{"comment": aw.comment}, Typeflag: tar.TypeXGlobalHeader}
+	aw.comment = ""
+	if headerErr := aw.tw.WriteHeader(hdr); headerErr != nil {
+		return fmt.Errorf("failed to write tar global header: %w", headerErr)
+	}
+
+	return nil
+}
+
+// zipArchiveWriter writes archives in the zip format.
+type zipArchiveWriter struct {
+	// modTime is the modification time of all entries.
+	modTime time.Time
+
+	// zw is the zip writer.
+	zw *zip.Writer
+}
+
+// newZipArchiveWriter creates a new zip archive writer that writes to the given writer with the given archive comment.
+func newZipArchiveWriter(w io.Writer, modTime time.Time, comment string) *zipArchiveWriter {
+	zw := zip.NewWriter(w)
+	// The comment is always valid since it is either empty or a commit hash.
+	_ = zw.SetComment(comment)
+
+	return &zipArchiveWriter{modTime: modTime, zw: zw}
+}
+
+func (aw *zipArchiveWriter) Close() error {
+	return aw.zw.Close()
+}
+
+func (aw *zipArchiveWriter) writeEntry(entry *archiveEntry) error {
+	hdr := &zip.FileHeader{Method: zip.Deflate, Modified: aw.modTime, Name: entry.name}
+	switch entry.mode {
+	case filemode.Dir:
+		hdr.Method = zip.Store
+		hdr.SetMode(os.ModeDir | archiveDirMode)
+	case filemode.Symlink:
+		hdr.Method = zip.Store
+		hdr.SetMode(os.ModeSymlink | archiveSymlinkMode)
+	case filemode.Executable:
+		hdr.SetMode(archiveExecutableMode)
+	default:
+		hdr.SetMode(archiveFileMode)
+	}
+
+	fw, headerErr := aw.zw.CreateHeader(hdr)
+	if headerErr != nil {
+		return fmt.Errorf("failed to write zip header of %q: %w", entry.name, headerErr)
+	}
+	// Like the Git "archive" command, the target of symbolic links is stored as content of the entry.
+	if entry.mode == filemode.Symlink {
+		if _, writeErr := io.WriteString(fw, entry.linkname); writeErr != nil {
+			return fmt.Errorf("failed to write %q to zip archive: %w", entry.name, writeErr)
+		}
+	}
+	if entry.content != nil {
+		if _, copyErr := io.Copy(fw, entry.content); copyErr != nil {
+			return fmt.Errorf("failed to write %q to zip archive: %w", entry.name, copyErr)
+		}
+	}
+
+	return nil
+}
+
+// expandFormatPlaceholders expands all "$Format:...$" placeholders of the given content for the given commit like the
+// Git "archive" command does for files with the "export-subst" attribute.
+func expandFormatPlaceholders(content []byte, commit *object.Commit) []byte {
+	const begin = "$Format:"
+	var b bytes.Buffer
+	for {
+		start := bytes.Index(content, []byte(begin))
+		if start < 0 {
+			break
+		}
+		end := bytes.IndexByte(content[start+len(begin):], '$')
+		if end < 0 {
+			break
+		}
+		b.Write(content[:start])
+		b.WriteString(expandPrettyFormat(string(content[start+len(begin):start+len(begin)+end]), commit))
+		content = content[start+len(begin)+end+1:]
+	}
+	b.Write(content)
+
+	return b.Bytes()
+}
+
+// expandPrettyFormat expands the placeholders of the given format for the given commit like the "--format" flag of
+// the Git "log" command. Unsupported placeholders are kept as they are.
+func expandPrettyFormat(format string, commit *object.Commit) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		value, consumed := expandPrettyPlaceholder(format[i+1:], commit)
+		if consumed == 0 {
+			b.WriteByte('%')
+			continue
+		}
+		b.WriteString(value)
+		i += consumed
+	}
+
+	return b.String()
+}
+
+// expandPrettyPlaceholder expands the placeholder at the start of the given format, without the leading percent sign,
+// for the given commit and returns the value and the amount of consumed bytes, which is 0 when the placeholder is not
+// supported.
+func expandPrettyPlaceholder(format string, commit *object.Commit) (string, int) {
+	if format == "" {
+		return "", 0
+	}
+
+	switch format[0] {
+	case '%':
+		return "%", 1
+	case 'n':
+		return "\n", 1
+	case 'H':
+		return commit.Hash.String(), 1
+	case 'h':
+		return abbrevHash(commit.Hash, DefaultHashAbbrevLength), 1
+	case 'T':
+		return commit.TreeHash.String(), 1
+	case 't':
+		return abbrevHash(commit.TreeHash, DefaultHashAbbrevLength), 1
+	case 'P', 'p':
+		parents := make([]string, len(commit.ParentHashes))
+		for i, parent := range commit.ParentHashes {
+			parents[i] = parent.String()
+			if format[0] == 'p' {
+				parents[i] = abbrevHash(parent, DefaultHashAbbrevLength)
+			}
+		}
+		return strings.Join(parents, " "), 1
+	case 's':
+		subject, _ := splitCommitMessage(commit.Message)
+		return subject, 1
+	case 'b':
+		_, body := splitCommitMessage(commit.Message)
+		return body, 1
+	case 'B':
+		return commit.Message, 1
+	case 'x':
+		if len(format) >= 3 {
+			if decoded, decodeErr := hex.DecodeString(format[1:3]); decodeErr == nil {
+				return string(decoded), 3
+			}
+		}
+	case 'a', 'c':
+		if len(format) < 2 {
+			return "", 0
+		}
+		sig := commit.Author
+		if format[0] == 'c' {
+			sig = commit.Committer
+		}
+		if value, ok := expandSignaturePlaceholder(format[1], sig); ok {
+			return value, 2
+		}
+	}
+
+	return "", 0
+}
+
+// expandSignaturePlaceholder expands the given placeholder of the author or committer for the given signature.
+func expandSignaturePlaceholder(placeholder byte, sig object.Signature) (string, bool) {
+	switch placeholder {
+	case 'n':
+		return sig.Name, true
+	case 'e':
+		return sig.Email, true
+	case 'd':
+		return sig.When.Format("Mon Jan 2 15:04:05 2006 -0700"), true
+	case 'D':
+		return sig.When.Format("Mon, 2 Jan 2006 15:04:05 -0700"), true
+	case 'i':
+		return sig.When.Format("2006-01-02 15:04:05 -0700"), true
+	case 'I':
+		return sig.When.Format("2006-01-02T15:04:05-07:00"), true
+	case 's':
+		return sig.When.Format("2006-01-02"), true
+	case 't':
+		return strconv.FormatInt(sig.When.Unix(), 10), true
+	default:
+		return "", false
+	}
+}
+
+// splitCommitMessage splits the given commit message into the subject, which is the first paragraph joined into a
+// single line, and the body.
+func splitCommitMessage(msg string) (string, string) {
+	parts := strings.SplitN(strings.TrimLeft(msg, "\n"), "\n\n", 2)
+	subject := strings.Join(strings.Split(strings.TrimRight(parts[0], "\n"), "\n"), " ")
+	if len(parts) == 1 {
+		return subject, ""
+	}
+
+	return subject, strings.TrimLeft(parts[1], "\n")
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+// archiveFile is a file of an archive.
+type archiveFile struct {
+	content  string
+	linkname string
+	mode     os.FileMode
+	modTime  time.Time
+}
+
+// archive exports the given revision of the repository as archive with the given options.
+func (tr *testRepository) archive(revision string, archiveOpts *glGit.ArchiveOptions) []byte {
+	tr.t.Helper()
+	var b bytes.Buffer
+	if err := glGit.Archive(tr.path, revision, &b, archiveOpts); err != nil {
+		assert.FailNow(tr.t, "failed to export archive", "revision: %q\nerror: %v", revision, err)
+	}
+
+	return b.Bytes()
+}
+
+// newArchiveTestRepository creates a test repository whose commit contains all kinds of files, attributes files and
+// symbolic links.
+func newArchiveTestRepository(t *testing.T) *testRepository {
+	t.Helper()
+	tr := newTestRepository(t)
+	tr.stageFile(".gitattributes", "/docs export-ignore\n*.tmp export-ignore\nVERSION export-subst\n")
+	tr.stageFile("README.md", "# golib\n")
+	tr.stageFile("VERSION", "$Format:%H %h %cI %s %an <%ae> %%%x41 %Z$\n$Format:%b$")
+	tr.stageFile("docs/guide.md", "# Guide\n")
+	tr.stageFile("notes.tmp", "")
+	tr.writeFile("scripts/build.sh", "#!/bin/sh\n")
+	if err := os.Chmod(filepath.Join(tr.path, "scripts", "build.sh"), 0o700); err != nil {
+		assert.FailNow(t, "failed to change file mode", "error: %v", err)
+	}
+	if err := os.Symlink("README.md", filepath.Join(tr.path, "link")); err != nil {
+		assert.FailNow(t, "failed to create symbolic link", "error: %v", err)
+	}
+	for _, name := range []string{"scripts/build.sh", "link"} {
+		if _, err := tr.worktree().Add(name); err != nil {
+			assert.FailNow(t, "failed to add file", "file: %q\nerror: %v", name, err)
+		}
+	}
+	tr.commitStaged("release\n\nThe first release.\n")
+
+	return tr
+}
+
+// readTar reads the files of the given tar archive by their name, the names in the order of the archive and the
+// comment of the global header.
+func readTar(t *testing.T, data []byte) (map[string]archiveFile, []string, string) {
+	t.Helper()
+	files := make(map[string]archiveFile)
+	var comment string
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			assert.FailNow(t, "failed to read tar archive", "error: %v", err)
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			comment = hdr.PAXRecords["comment"]
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			assert.FailNow(t, "failed to read tar archive", "file: %q\nerror: %v", hdr.Name, err)
+		}
+		names = append(names, hdr.Name)
+		files[hdr.Name] = archiveFile{
+			content:  string(content),
+			linkname: hdr.Linkname,
+			mode:     hdr.FileInfo().Mode(),
+			modTime:  hdr.ModTime,
+		}
+	}
+
+	return files, names, comment
+}
+
+func TestArchive(t *testing.T) {
+	tr := newArchiveTestRepository(t)
+	head, err := tr.repo.Head()
+	if err != nil {
+		assert.FailNow(t, "failed to get HEAD", "error: %v", err)
+	}
+	hash := head.Hash().String()
+
+	tarData := tr.archive("", nil)
+	assert.Equal(t, tarData, tr.archive("", nil), "archives of the same commit must be byte-identical")
+
+	files, names, comment := readTar(t, tarData)
+	assert.Equal(t, hash, comment)
+	assert.Equal(t, []string{
+		".gitattributes",
+		"README.md",
+		"VERSION",
+		"link",
+		"scripts/",
+		"scripts/build.sh",
+	}, names)
+	commitTime := time.Date(2020, 11, 21, 12, 1, 0, 0, time.UTC)
+	for name, f := range files {
+		assert.True(t, commitTime.Equal(f.modTime), "file: %q\nmodification time: %s", name, f.modTime)
+	}
+	assert.Equal(t, os.FileMode(0o644), files["README.md"].mode)
+	assert.Equal(t, os.FileMode(0o755), files["scripts/build.sh"].mode)
+	assert.Equal(t, os.ModeDir|0o755, files["scripts/"].mode)
+	assert.Equal(t, os.ModeSymlink|0o777, files["link"].mode)
+	assert.Equal(t, "README.md", files["link"].linkname)
+	assert.Equal(t,
+		hash+" "+hash[:glGit.DefaultHashAbbrevLength]+
+			" 2020-11-21T12:01:00+00:00 release Sven Greb <development@svengreb.de> %A %Z\nThe first release.\n",
+		files["VERSION"].content)
+
+	zr, err := gzip.NewReader(bytes.NewReader(tr.archive("", &glGit.ArchiveOptions{Format: glGit.ArchiveTarGz})))
+	if err != nil {
+		assert.FailNow(t, "failed to read gzip archive", "error: %v", err)
+	}
+	decompressed, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Equal(t, tarData, decompressed)
+	assert.True(t, zr.ModTime.IsZero())
+	assert.Empty(t, zr.Name)
+
+	_, names, _ = readTar(t, tr.archive("HEAD", &glGit.ArchiveOptions{Prefix: "golib-1.0.0"}))
+	assert.Equal(t, []string{"golib-1.0.0/", "golib-1.0.0/.gitattributes"}, names[:2])
+}
+
+func TestArchive_Zip(t *testing.T) {
+	tr := newArchiveTestRepository(t)
+	modTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	archiveOpts := &glGit.ArchiveOptions{Format: glGit.ArchiveZip, ModTime: modTime, Prefix: "golib/"}
+	data := tr.archive("", archiveOpts)
+	assert.Equal(t, data, tr.archive("", archiveOpts))
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		assert.FailNow(t, "failed to read zip archive", "error: %v", err)
+	}
+	head, err := tr.repo.Head()
+	if err != nil {
+		assert.FailNow(t, "failed to get HEAD", "error: %v", err)
+	}
+	assert.Equal(t, head.Hash().String(), zr.Comment)
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+		assert.True(t, modTime.Equal(f.Modified), "file: %q\nmodification time: %s", f.Name, f.Modified)
+	}
+	assert.Len(t, files, 7)
+	assert.Equal(t, os.ModeDir|0o755, files["golib/"].Mode())
+	assert.Equal(t, os.FileMode(0o644), files["golib/README.md"].Mode())
+	assert.Equal(t, os.FileMode(0o755), files["golib/scripts/build.sh"].Mode())
+	assert.Equal(t, os.ModeSymlink|0o777, files["golib/link"].Mode())
+	link, err := files["golib/link"].Open()
+	if assert.NoError(t, err) {
+		target, readErr := io.ReadAll(link)
+		assert.NoError(t, readErr)
+		assert.Equal(t, "README.md", string(target))
+		assert.NoError(t, link.Close())
+	}
+	assert.NotContains(t, files, "golib/docs/")
+	assert.NotContains(t, files, "golib/notes.tmp")
+}
+
+func TestArchive_Tree(t *testing.T) {
+	tr := newArchiveTestRepository(t)
+	commit, err := tr.repo.CommitObject(tr.commitStaged("second"))
+	if err != nil {
+		assert.FailNow(t, "failed to get commit", "error: %v", err)
+	}
+
+	// Placeholders are only expanded when a commit is exported.
+	files, _, comment := readTar(t, tr.archive(commit.TreeHash.String(), nil))
+	assert.Empty(t, comment)
+	assert.Equal(t, "$Format:%H %h %cI %s %an <%ae> %%%x41 %Z$\n$Format:%b$", files["VERSION"].content)
+	assert.True(t, time.Unix(0, 0).Equal(files["README.md"].modTime))
+}
+
+func TestArchive_InfoAttributes(t *testing.T) {
+	tr := newArchiveTestRepository(t)
+	// The "info/attributes" file of the Git directory takes precedence over the attributes files of the tree.
+	tr.writeFile(filepath.Join(".git", "info", "attributes"), "README.md export-ignore\n/docs -export-ignore\n")
+
+	_, names, _ := readTar(t, tr.archive("", nil))
+	assert.NotContains(t, names, "README.md")
+	assert.Contains(t, names, "docs/")
+	assert.Contains(t, names, "docs/guide.md")
+	assert.NotContains(t, names, "notes.tmp")
+}
+
+func TestArchive_Errors(t *testing.T) {
+	tr := newArchiveTestRepository(t)
+
+	for _, prefix := range []string{"/abs", "..", "../golib", "."} {
+		err := glGit.Archive(tr.path, "", io.Discard, &glGit.ArchiveOptions{Prefix: prefix})
+		assert.True(t, errors.Is(err, glGit.ErrInvalidOption), "prefix: %q\nerror: %v", prefix, err)
+	}
+	err := glGit.Archive(tr.path, "", io.Discard, &glGit.ArchiveOptions{Format: glGit.ArchiveFormat(42)})
+	assert.True(t, errors.Is(err, glGit.ErrInvalidOption))
+	err = glGit.Archive(tr.path, "v9.9.9", io.Discard, nil)
+	assert.True(t, errors.Is(err, glGit.ErrRevisionNotFound))
+	err = glGit.ArchiveFromRepository(nil, "", io.Discard, nil)
+	assert.True(t, errors.Is(err, glGit.ErrRepositoryNotFound))
+}
+
+func TestParseArchiveFormat(t *testing.T) {
+	for name, expected := range map[string]glGit.ArchiveFormat{
+		"tar":    glGit.ArchiveTar,
+		"tar.gz": glGit.ArchiveTarGz,
+		"TGZ":    glGit.ArchiveTarGz,
+		"zip":    glGit.ArchiveZip,
+	} {
+		format, err := glGit.ParseArchiveFormat(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, format)
+	}
+
+	_, err := glGit.ParseArchiveFormat("7z")
+	assert.True(t, errors.Is(err, glGit.ErrInvalidOption))
+	assert.Equal(t, "tar.gz", glGit.ArchiveTarGz.String())
+}
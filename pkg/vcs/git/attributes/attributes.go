@@ -0,0 +1,348 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+// Package attributes provides a parser for Git attributes files and a matcher to look up the attributes of paths the
+// same way as Git does, e.g. to respect the "eol", "binary", "export-ignore" or "linguist-*" attributes.
+// See https://git-scm.com/docs/gitattributes for more details about Git attributes.
+package attributes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ExportIgnore is the name of the attribute that excludes paths from archives created through the Git "archive"
+	// command.
+	ExportIgnore = "export-ignore"
+
+	// ExportSubst is the name of the attribute that enables the expansion of "$Format:...$" placeholders in files of
+	// archives created through the Git "archive" command.
+	ExportSubst = "export-subst"
+
+	// FileName is the name of Git attributes files.
+	FileName = ".gitattributes"
+
+	// MacroBinary is the name of the built-in macro attribute that unsets the "diff", "merge" and "text" attributes.
+	MacroBinary = "binary"
+)
+
+// builtinMacros are the macro attributes that are defined by Git itself.
+var builtinMacros = map[string][]Attribute{
+	MacroBinary: {{Name: "diff", State: Unset}, {Name: "merge", State: Unset}, {Name: "text", State: Unset}},
+}
+
+// State is the state of an attribute for a path.
+type State int
+
+const (
+	// Unspecified indicates that no pattern matches the path to set or unset the attribute, or that the attribute has
+	// been reverted to this state through the "!attr" syntax.
+	Unspecified State = iota
+
+	// Set indicates that the attribute has been set through the "attr" syntax.
+	Set
+
+	// Unset indicates that the attribute has been unset through the "-attr" syntax.
+	Unset
+
+	// Value indicates that the attribute has been set to a value through the "attr=value" syntax.
+	Value
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case Unspecified:
+		return "unspecified"
+	case Set:
+		return "set"
+	case Unset:
+		return "unset"
+	case Value:
+		return "value"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Attribute is the state of a single attribute.
+type Attribute struct {
+	// Name is the name of the attribute.
+	Name string
+
+	// State is the state of the attribute.
+	State State
+
+	// Value is the value of the attribute when the state is Value.
+	Value string
+}
+
+// Attributes are the attributes of a path by their name.
+// Attributes in the Unspecified state are not included.
+type Attributes map[string]Attribute
+
+// Get returns the attribute with the given name, which is in the Unspecified state when not included.
+func (a Attributes) Get(name string) Attribute {
+	if attr, ok := a[name]; ok {
+		return attr
+	}
+
+	return Attribute{Name: name}
+}
+
+// IsSet checks if the attribute with the given name is in the Set state.
+func (a Attributes) IsSet(name string) bool {
+	return a.Get(name).State == Set
+}
+
+// IsUnset checks if the attribute with the given name is in the Unset state.
+func (a Attributes) IsUnset(name string) bool {
+	return a.Get(name).State == Unset
+}
+
+// Value returns the value of the attribute with the given name and whether it is in the Value state.
+func (a Attributes) Value(name string) (string, bool) {
+	attr := a.Get(name)
+	return attr.Value, attr.State == Value
+}
+
+// File is a parsed Git attributes file.
+type File struct {
+	// Dir is the slash-separated directory of the file relative to the root directory of the repository, or empty for
+	// the root directory.
+	Dir string
+
+	// info indicates whether the file is the "info/attributes" file of the Git directory that takes precedence over all
+	// attributes files of the worktree.
+	info bool
+
+	// macros are the macro attribute definitions.
+	macros []macro
+
+	// rules are the pattern lines in the order they are defined.
+	rules []rule
+}
+
+// macro is the definition of a macro attribute.
+type macro struct {
+	attrs []Attribute
+	name  string
+}
+
+// rule is a pattern together with the attributes it assigns to matching paths.
+type rule struct {
+	attrs   []Attribute
+	pattern *Pattern
+}
+
+// Matcher looks up the attributes of paths from attributes files the same way as Git.
+type Matcher struct {
+	// files are the attributes files ordered by their precedence, highest first.
+	files []*File
+
+	// macros are the definitions of macro attributes by their name.
+	macros map[string][]Attribute
+}
+
+// Parse parses the Git attributes file in the given directory, relative to the root directory of the repository, from
+// the given reader.
+// Like Git, comment lines starting with a "#", negative patterns and attributes with invalid names are ignored while
+// macro attributes can only be defined in the root directory.
+func Parse(r io.Reader, dir string) (*File, error) {
+	f := &File{Dir: normalizePath(dir)}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		f.parseLine(scanner.Text())
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return nil, fmt.Errorf("failed to read attributes file in %q: %w", dir, scanErr)
+	}
+
+	return f, nil
+}
+
+// ParseInfo parses the "info/attributes" file of the Git directory from the given reader.
+// Like Git, the file takes precedence over all attributes files of the worktree when passed to NewMatcher.
+func ParseInfo(r io.Reader) (*File, error) {
+	f, parseErr := Parse(r, "")
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	f.info = true
+
+	return f, nil
+}
+
+// parseLine parses a single line of an attributes file.
+func (f *File) parseLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	var text, rest string
+	if strings.HasPrefix(line, `"`) {
+		quoted, quoteErr := strconv.QuotedPrefix(line)
+		if quoteErr != nil {
+			return
+		}
+		rest = line[len(quoted):]
+		if text, quoteErr = strconv.Unquote(quoted); quoteErr != nil {
+			return
+		}
+	} else {
+		text = line
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			text, rest = line[:i], line[i:]
+		}
+	}
+	attrs := parseAttributes(strings.Fields(rest))
+
+	if strings.HasPrefix(text, "[attr]") {
+		name := strings.TrimPrefix(text, "[attr]")
+		if f.Dir == "" && isValidName(name) {
+			f.macros = append(f.macros, macro{attrs: attrs, name: name})
+		}
+		return
+	}
+	// Like Git, negative patterns are not allowed in attributes files.
+	if strings.HasPrefix(text, "!") {
+		return
+	}
+	if p, ok := NewPattern(text, f.Dir); ok {
+		f.rules = append(f.rules, rule{attrs: attrs, pattern: p})
+	}
+}
+
+// NewMatcher creates a new matcher for the given attributes files.
+// Files in deeper directories take precedence over files in their parent directories while files in the same directory
+// take precedence in the given order. Files parsed through ParseInfo take precedence over all other files.
+// Macro attributes defined in later files override those defined in earlier files and the built-in "binary" macro.
+func NewMatcher(files ...*File) *Matcher {
+	sorted := make([]*File, len(files))
+	copy(sorted, files)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].info != sorted[j].info {
+			return sorted[j].info
+		}
+		return depth(sorted[i].Dir) < depth(sorted[j].Dir)
+	})
+
+	m := &Matcher{files: make([]*File, 0, len(sorted)), macros: make(map[string][]Attribute)}
+	for name, attrs := range builtinMacros {
+		m.macros[name] = attrs
+	}
+	for _, f := range sorted {
+		for _, mac := range f.macros {
+			m.macros[mac.name] = mac.attrs
+		}
+	}
+	for i := len(sorted) - 1; i >= 0; i-- {
+		m.files = append(m.files, sorted[i])
+	}
+
+	return m
+}
+
+// Match returns the attributes of the given path relative to the root directory of the repository.
+// Patterns ending with a slash only match when the path is a directory.
+// Like Git, the attributes are taken from the line with the highest precedence that assigns them, which is the last
+// matching line of the file with the highest precedence, and macro attributes are expanded when set.
+func (m *Matcher) Match(name string, isDir bool) Attributes {
+	name = normalizePath(name)
+	result := make(Attributes)
+	assigned := make(map[string]bool)
+	for _, f := range m.files {
+		if f.Dir != "" && !strings.HasPrefix(name, f.Dir+"/") {
+			continue
+		}
+		for i := len(f.rules) - 1; i >= 0; i-- {
+			if f.rules[i].pattern.Match(name, isDir) {
+				m.fill(f.rules[i].attrs, result, assigned)
+			}
+		}
+	}
+
+	return result
+}
+
+// fill assigns the given attributes that have not been assigned yet, in reverse order so that later attributes of the
+// same line take precedence, and expands macro attributes that are set.
+func (m *Matcher) fill(attrs []Attribute, result Attributes, assigned map[string]bool) {
+	for i := len(attrs) - 1; i >= 0; i-- {
+		attr := attrs[i]
+		if assigned[attr.Name] {
+			continue
+		}
+		assigned[attr.Name] = true
+		if attr.State != Unspecified {
+			result[attr.Name] = attr
+		}
+		if expanded, ok := m.macros[attr.Name]; ok && attr.State == Set {
+			m.fill(expanded, result, assigned)
+		}
+	}
+}
+
+// parseAttributes parses the given attribute fields of a line while fields with invalid names are ignored.
+func parseAttributes(fields []string) []Attribute {
+	attrs := make([]Attribute, 0, len(fields))
+	for _, field := range fields {
+		var attr Attribute
+		switch {
+		case strings.HasPrefix(field, "-"):
+			attr = Attribute{Name: field[1:], State: Unset}
+		case strings.HasPrefix(field, "!"):
+			attr = Attribute{Name: field[1:], State: Unspecified}
+		case strings.Contains(field, "="):
+			i := strings.IndexByte(field, '=')
+			attr = Attribute{Name: field[:i], State: Value, Value: field[i+1:]}
+		default:
+			attr = Attribute{Name: field, State: Set}
+		}
+		if isValidName(attr.Name) {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	return attrs
+}
+
+// isValidName checks if the given attribute name only consists of ASCII letters, digits, dashes, dots and underscores
+// and does not start with a dash.
+func isValidName(name string) bool {
+	if name == "" || name[0] == '-' {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '.', c == '_':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizePath converts the given path into a clean slash-separated path relative to the root directory, which is
+// empty for the root directory itself.
+func normalizePath(name string) string {
+	return strings.Trim(path.Clean("/"+filepath.ToSlash(name)), "/")
+}
+
+// depth returns the amount of segments of the given normalized directory.
+func depth(dir string) int {
+	if dir == "" {
+		return 0
+	}
+
+	return strings.Count(dir, "/") + 1
+}
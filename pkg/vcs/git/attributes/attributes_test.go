@@ -0,0 +1,208 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package attributes_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/svengreb/golib/pkg/vcs/git/attributes"
+)
+
+// parse parses the given content of an attributes file in the given directory.
+func parse(t *testing.T, dir, content string) *attributes.File {
+	t.Helper()
+	f, err := attributes.Parse(strings.NewReader(content), dir)
+	if err != nil {
+		assert.FailNow(t, "failed to parse attributes file", "dir: %q\nerror: %v", dir, err)
+	}
+
+	return f
+}
+
+func TestMatcher_Match(t *testing.T) {
+	root := parse(t, "", `# Copyright and other comments are ignored.
+[attr]generated linguist-generated -diff
+* text=auto eol=lf
+*.png binary
+*.bat eol=crlf
+"quoted name.txt" -text
+!negative.txt text
+*.md text binary
+*.svg binary text
+*.go -invalid@name diff=golang
+*.pb.go generated
+`)
+	nested := parse(t, "pkg", `*.go text eol=crlf
+[attr]ignored -text
+*.txt ignored
+z.go !eol
+`)
+	m := attributes.NewMatcher(root, nested)
+
+	testCases := []struct {
+		expected attributes.Attributes
+		isDir    bool
+		name     string
+	}{
+		{
+			name: "README",
+			expected: attributes.Attributes{
+				"text": {Name: "text", State: attributes.Value, Value: "auto"},
+				"eol":  {Name: "eol", State: attributes.Value, Value: "lf"},
+			},
+		},
+		{
+			name: "assets/logo.png",
+			expected: attributes.Attributes{
+				"binary": {Name: "binary", State: attributes.Set},
+				"diff":   {Name: "diff", State: attributes.Unset},
+				"merge":  {Name: "merge", State: attributes.Unset},
+				"text":   {Name: "text", State: attributes.Unset},
+				"eol":    {Name: "eol", State: attributes.Value, Value: "lf"},
+			},
+		},
+		{
+			name: "scripts/build.bat",
+			expected: attributes.Attributes{
+				"text": {Name: "text", State: attributes.Value, Value: "auto"},
+				"eol":  {Name: "eol", State: attributes.Value, Value: "crlf"},
+			},
+		},
+		{
+			name: "quoted name.txt",
+			expected: attributes.Attributes{
+				"text": {Name: "text", State: attributes.Unset},
+				"eol":  {Name: "eol", State: attributes.Value, Value: "lf"},
+			},
+		},
+		{
+			name: "generated/api.pb.go",
+			expected: attributes.Attributes{
+				"generated":          {Name: "generated", State: attributes.Set},
+				"linguist-generated": {Name: "linguist-generated", State: attributes.Set},
+				"diff":               {Name: "diff", State: attributes.Unset},
+				"text":               {Name: "text", State: attributes.Value, Value: "auto"},
+				"eol":                {Name: "eol", State: attributes.Value, Value: "lf"},
+			},
+		},
+		{
+			// Later attributes of the same line take precedence over attributes of expanded macros.
+			name: "README.md",
+			expected: attributes.Attributes{
+				"binary": {Name: "binary", State: attributes.Set},
+				"diff":   {Name: "diff", State: attributes.Unset},
+				"merge":  {Name: "merge", State: attributes.Unset},
+				"text":   {Name: "text", State: attributes.Unset},
+				"eol":    {Name: "eol", State: attributes.Value, Value: "lf"},
+			},
+		},
+		{
+			name: "logo.svg",
+			expected: attributes.Attributes{
+				"binary": {Name: "binary", State: attributes.Set},
+				"diff":   {Name: "diff", State: attributes.Unset},
+				"merge":  {Name: "merge", State: attributes.Unset},
+				"text":   {Name: "text", State: attributes.Set},
+				"eol":    {Name: "eol", State: attributes.Value, Value: "lf"},
+			},
+		},
+		{
+			// Files in deeper directories take precedence while macros can only be defined in the root directory.
+			name: "pkg/a/z.go",
+			expected: attributes.Attributes{
+				"diff": {Name: "diff", State: attributes.Value, Value: "golang"},
+				"text": {Name: "text", State: attributes.Set},
+			},
+		},
+		{
+			name: "pkg/notes.txt",
+			expected: attributes.Attributes{
+				"ignored": {Name: "ignored", State: attributes.Set},
+				"text":    {Name: "text", State: attributes.Value, Value: "auto"},
+				"eol":     {Name: "eol", State: attributes.Value, Value: "lf"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, m.Match(tc.name, tc.isDir), "path: %q", tc.name)
+	}
+
+	negative := m.Match("negative.txt", false)
+	assert.True(t, negative.Get("text").State == attributes.Value)
+	assert.Equal(t, attributes.Attribute{Name: "missing"}, negative.Get("missing"))
+}
+
+func TestMatcher_MatchPatterns(t *testing.T) {
+	m := attributes.NewMatcher(parse(t, "", `/anchored.txt export-ignore
+docs/ export-ignore
+vendor/** linguist-vendored
+**/testdata/** linguist-generated
+a/**/z.go linguist-documentation
+*.[!c] linguist-detectable
+`))
+
+	testCases := []struct {
+		attr     string
+		expected bool
+		isDir    bool
+		name     string
+	}{
+		{attr: "export-ignore", name: "anchored.txt", expected: true},
+		{attr: "export-ignore", name: "sub/anchored.txt"},
+		{attr: "export-ignore", name: "docs", isDir: true, expected: true},
+		{attr: "export-ignore", name: "sub/docs", isDir: true, expected: true},
+		// Patterns that match a directory do not recursively match paths inside of it.
+		{attr: "export-ignore", name: "docs/index.md"},
+		{attr: "export-ignore", name: "docs"},
+		{attr: "linguist-vendored", name: "vendor/a/b.go", expected: true},
+		{attr: "linguist-vendored", name: "vendor", isDir: true},
+		{attr: "linguist-vendored", name: "sub/vendor/a.go"},
+		{attr: "linguist-generated", name: "testdata/a.txt", expected: true},
+		{attr: "linguist-generated", name: "a/b/testdata/c/d.txt", expected: true},
+		{attr: "linguist-documentation", name: "a/z.go", expected: true},
+		{attr: "linguist-documentation", name: "a/b/c/z.go", expected: true},
+		{attr: "linguist-documentation", name: "b/a/z.go"},
+		{attr: "linguist-detectable", name: "main.h", expected: true},
+		{attr: "linguist-detectable", name: "main.c"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, m.Match(tc.name, tc.isDir).IsSet(tc.attr), "path: %q\nattribute: %q", tc.name, tc.attr)
+	}
+}
+
+func TestMatcher_MatchInfo(t *testing.T) {
+	info, err := attributes.ParseInfo(strings.NewReader("[attr]docs linguist-documentation\n*.md eol=crlf docs\n"))
+	if err != nil {
+		assert.FailNow(t, "failed to parse info attributes file", "error: %v", err)
+	}
+	// The info file takes precedence regardless of the order and the directory of the other files.
+	m := attributes.NewMatcher(info, parse(t, "", "*.md eol=lf\n"), parse(t, "docs/api", "*.md eol=lf -docs\n"))
+
+	attrs := m.Match("docs/api/index.md", false)
+	eol, _ := attrs.Value("eol")
+	assert.Equal(t, "crlf", eol)
+	assert.True(t, attrs.IsSet("linguist-documentation"))
+}
+
+func TestAttributes(t *testing.T) {
+	attrs := attributes.Attributes{
+		"eol":  {Name: "eol", State: attributes.Value, Value: "lf"},
+		"diff": {Name: "diff", State: attributes.Unset},
+	}
+
+	value, ok := attrs.Value("eol")
+	assert.True(t, ok)
+	assert.Equal(t, "lf", value)
+	_, ok = attrs.Value("diff")
+	assert.False(t, ok)
+	assert.True(t, attrs.IsUnset("diff"))
+	assert.False(t, attrs.IsSet("diff"))
+	assert.False(t, attrs.IsUnset("text"))
+	assert.Equal(t, "unspecified", attrs.Get("text").State.String())
+}
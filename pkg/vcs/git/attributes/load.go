@@ -0,0 +1,112 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package attributes
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// infoAttributesPath is the path of the repository specific attributes file relative to the Git directory.
+var infoAttributesPath = filepath.Join("info", "attributes")
+
+// Load creates a matcher from all attributes files of the worktree with the given root directory and the
+// "info/attributes" file of its ".git" directory, which takes precedence over all other files.
+// Like Git, the ".git" directory, nested repositories like submodules and attributes files that are no regular files,
+// e.g. symbolic links, are not taken into account.
+func Load(root string) (*Matcher, error) {
+	var files []*File
+	walkErr := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if p != root {
+				if _, statErr := os.Lstat(filepath.Join(p, ".git")); statErr == nil {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if d.Name() != FileName || !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, relErr := filepath.Rel(root, filepath.Dir(p))
+		if relErr != nil {
+			return relErr
+		}
+		f, parseErr := parseFile(p, rel)
+		if parseErr != nil {
+			return parseErr
+		}
+		files = append(files, f)
+		return nil
+	})
+	if walkErr != nil {
+		return nil, fmt.Errorf("failed to load attributes files from %q: %w", root, walkErr)
+	}
+
+	info, parseErr := parseFile(filepath.Join(root, ".git", infoAttributesPath), "")
+	switch {
+	case errors.Is(parseErr, fs.ErrNotExist):
+	case parseErr != nil:
+		return nil, parseErr
+	default:
+		info.info = true
+		files = append(files, info)
+	}
+
+	return NewMatcher(files...), nil
+}
+
+// LoadTree creates a matcher from all attributes files of the given tree, e.g. the tree of a commit to look up the
+// attributes like the Git "archive" command does, and the given additional files, like the "info/attributes" file of
+// the Git directory parsed through ParseInfo.
+// Like Git, attributes files that are no regular files, e.g. symbolic links, are not taken into account.
+func LoadTree(tree *object.Tree, extra ...*File) (*Matcher, error) {
+	var files []*File
+	iterErr := tree.Files().ForEach(func(file *object.File) error {
+		if path.Base(file.Name) != FileName || file.Mode == filemode.Symlink {
+			return nil
+		}
+		r, readerErr := file.Reader()
+		if readerErr != nil {
+			return fmt.Errorf("failed to read %q: %w", file.Name, readerErr)
+		}
+		defer func() { _ = r.Close() }()
+		f, parseErr := Parse(r, path.Dir(file.Name))
+		if parseErr != nil {
+			return parseErr
+		}
+		files = append(files, f)
+		return nil
+	})
+	if iterErr != nil {
+		return nil, fmt.Errorf("failed to load attributes files from tree %s: %w", tree.Hash, iterErr)
+	}
+
+	return NewMatcher(append(files, extra...)...), nil
+}
+
+// parseFile parses the attributes file at the given path for the given directory.
+func parseFile(name, dir string) (*File, error) {
+	f, openErr := os.Open(name)
+	if openErr != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", name, openErr)
+	}
+	defer func() { _ = f.Close() }()
+
+	return Parse(f, dir)
+}
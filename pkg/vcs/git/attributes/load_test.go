@@ -0,0 +1,111 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package attributes_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/svengreb/golib/pkg/vcs/git/attributes"
+)
+
+// writeFiles writes the given files by their slash-separated path relative to the given root directory.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			assert.FailNow(t, "failed to create directory", "file: %q\nerror: %v", name, err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			assert.FailNow(t, "failed to write file", "file: %q\nerror: %v", name, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitattributes":          "* text=auto eol=lf\n*.png binary\n",
+		"docs/.gitattributes":     "*.md linguist-documentation\n",
+		"docs/api/.gitattributes": "*.md -linguist-documentation eol=lf\n",
+		".git/info/attributes":    "*.md eol=crlf\n",
+		".git/.gitattributes":     "* export-ignore\n",
+		"module/.git/HEAD":        "ref: refs/heads/main\n",
+		"module/.gitattributes":   "* export-ignore\n",
+	})
+
+	m, err := attributes.Load(root)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	attrs := m.Match("docs/guide.md", false)
+	assert.True(t, attrs.IsSet("linguist-documentation"))
+	eol, _ := attrs.Value("eol")
+	assert.Equal(t, "crlf", eol)
+	assert.True(t, m.Match("docs/api/index.md", false).IsUnset("linguist-documentation"))
+	// The "info/attributes" file takes precedence over attributes files in nested directories.
+	eol, _ = m.Match("docs/api/index.md", false).Value("eol")
+	assert.Equal(t, "crlf", eol)
+	assert.True(t, m.Match("assets/logo.png", false).IsSet(attributes.MacroBinary))
+	assert.False(t, m.Match("module/main.go", false).IsSet("export-ignore"))
+	assert.False(t, m.Match(".git/config", false).IsSet("export-ignore"))
+
+	_, err = attributes.Load(filepath.Join(root, "missing"))
+	assert.Error(t, err)
+}
+
+func TestLoadTree(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".gitattributes":     "* text=auto\n/docs export-ignore\n",
+		"pkg/.gitattributes": "*.go linguist-generated\n",
+		"pkg/a.go":           "package pkg\n",
+	})
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		assert.FailNow(t, "failed to initialize repository", "path: %q\nerror: %v", dir, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		assert.FailNow(t, "failed to get worktree", "error: %v", err)
+	}
+	for _, name := range []string{".gitattributes", "pkg/.gitattributes", "pkg/a.go"} {
+		if _, err = wt.Add(name); err != nil {
+			assert.FailNow(t, "failed to add file", "file: %q\nerror: %v", name, err)
+		}
+	}
+	sig := &object.Signature{Name: "Sven Greb", Email: "development@svengreb.de", When: time.Unix(0, 0)}
+	hash, err := wt.Commit("first", &git.CommitOptions{Author: sig})
+	if err != nil {
+		assert.FailNow(t, "failed to commit", "error: %v", err)
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		assert.FailNow(t, "failed to get commit", "error: %v", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		assert.FailNow(t, "failed to get tree", "error: %v", err)
+	}
+
+	// Attributes files in the worktree that are not committed are not taken into account.
+	writeFiles(t, dir, map[string]string{"pkg/.gitattributes": "*.go -linguist-generated\n"})
+
+	m, err := attributes.LoadTree(tree)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, m.Match("pkg/a.go", false).IsSet("linguist-generated"))
+	assert.True(t, m.Match("docs", true).IsSet("export-ignore"))
+	value, _ := m.Match("main.go", false).Value("text")
+	assert.Equal(t, "auto", value)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package attributes
+
+import (
+	"path"
+	"strings"
+)
+
+// Pattern is a path pattern of an attributes or ignore file.
+// Like Git, the rules by which a pattern matches paths are the same for attributes and ".gitignore" files, except that
+// negative patterns are only allowed in ignore files and patterns of attributes files that match a directory do not
+// recursively match paths inside of it.
+type Pattern struct {
+	// anchored indicates whether the pattern contains a slash and therefore is matched against the path relative to
+	// the directory of the file instead of only against the last path segment.
+	anchored bool
+
+	// dir is the slash-separated directory of the file relative to the root directory, or empty for the root directory.
+	dir string
+
+	// mustBeDir indicates whether the pattern ends with a slash and therefore only matches directories.
+	mustBeDir bool
+
+	// segments are the slash-separated segments of the pattern.
+	segments []string
+}
+
+// NewPattern creates a new pattern for a file in the given slash-separated directory relative to the root directory.
+// It returns false when the text is no valid pattern, e.g. an empty pattern or only slashes.
+// Note that the "!" prefix of negative patterns in ignore files must be stripped before while the pattern is matched
+// literally otherwise.
+func NewPattern(text, dir string) (*Pattern, bool) {
+	if text == "" {
+		return nil, false
+	}
+
+	p := &Pattern{dir: normalizePath(dir)}
+	if strings.HasSuffix(text, "/") {
+		p.mustBeDir = true
+		text = strings.TrimRight(text, "/")
+	}
+	if strings.HasPrefix(text, "/") {
+		p.anchored = true
+		text = strings.TrimLeft(text, "/")
+	}
+	if text == "" {
+		return nil, false
+	}
+	if strings.Contains(text, "/") {
+		p.anchored = true
+	}
+	for _, segment := range strings.Split(text, "/") {
+		if segment == "" {
+			continue
+		}
+		if segment != "**" {
+			// Consecutive asterisks within a segment have the same meaning as a single one.
+			for strings.Contains(segment, "**") {
+				segment = strings.ReplaceAll(segment, "**", "*")
+			}
+			// Git also supports the "[!...]" syntax to negate a character class.
+			segment = strings.ReplaceAll(segment, "[!", "[^")
+		}
+		p.segments = append(p.segments, segment)
+	}
+
+	return p, true
+}
+
+// Match checks if the pattern matches the given slash-separated path relative to the root directory.
+// Patterns ending with a slash only match when the path is a directory.
+func (p *Pattern) Match(name string, isDir bool) bool {
+	if p.mustBeDir && !isDir {
+		return false
+	}
+	if p.dir != "" {
+		if !strings.HasPrefix(name, p.dir+"/") {
+			return false
+		}
+		name = name[len(p.dir)+1:]
+	}
+
+	segments := strings.Split(name, "/")
+	if !p.anchored {
+		return matchSegment(p.segments[0], segments[len(segments)-1])
+	}
+
+	return matchSegments(p.segments, segments)
+}
+
+// matchSegments checks if the given pattern segments match all of the given path segments.
+// The "**" pattern segment matches zero or more path segments.
+func matchSegments(patterns, segments []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			rest := patterns[1:]
+			if len(rest) == 0 {
+				// A trailing "**" matches everything inside, but not the directory itself.
+				return len(segments) > 0
+			}
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(rest, segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 || !matchSegment(patterns[0], segments[0]) {
+			return false
+		}
+		patterns, segments = patterns[1:], segments[1:]
+	}
+
+	return len(segments) == 0
+}
+
+// matchSegment checks if the given pattern segment matches the given path segment.
+// Malformed patterns never match.
+func matchSegment(pattern, segment string) bool {
+	matched, err := path.Match(pattern, segment)
+	return err == nil && matched
+}
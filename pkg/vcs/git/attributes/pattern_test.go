@@ -0,0 +1,47 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package attributes_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/svengreb/golib/pkg/vcs/git/attributes"
+)
+
+func TestPattern_Match(t *testing.T) {
+	testCases := []struct {
+		dir      string
+		expected bool
+		isDir    bool
+		name     string
+		text     string
+	}{
+		{text: "*.log", name: "a/b/debug.log", expected: true},
+		{text: "/build/", name: "build", isDir: true, expected: true},
+		{text: "/build/", name: "build"},
+		{text: "/build/", name: "pkg/build", isDir: true},
+		{text: "/only-here.txt", dir: "pkg", name: "pkg/only-here.txt", expected: true},
+		{text: "/only-here.txt", dir: "pkg", name: "pkg/sub/only-here.txt"},
+		{text: "/only-here.txt", dir: "pkg", name: "only-here.txt"},
+		// A trailing "**" matches everything inside, but not the directory itself.
+		{text: "docs/**", name: "docs/a/index.md", expected: true},
+		{text: "docs/**", name: "docs", isDir: true},
+		{text: "!important.log", name: "!important.log", expected: true},
+		{text: `\#hash`, name: "#hash", expected: true},
+	}
+
+	for _, tc := range testCases {
+		p, ok := attributes.NewPattern(tc.text, tc.dir)
+		if assert.True(t, ok, "pattern: %q", tc.text) {
+			assert.Equal(t, tc.expected, p.Match(tc.name, tc.isDir), "pattern: %q\npath: %q", tc.text, tc.name)
+		}
+	}
+
+	for _, text := range []string{"", "/", "//"} {
+		_, ok := attributes.NewPattern(text, "")
+		assert.False(t, ok, "pattern: %q", text)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// BranchRule maps branches to a version constraint, e.g. to only consider the tags of a maintenance branch.
+type BranchRule struct {
+	// Branch is a glob pattern that is matched against the short name of the branch HEAD is pointing to using the rules
+	// of the "path.Match" function, e.g. "release/1.4" or "v2.x".
+	Branch string
+
+	// Constraint is the version constraint in the format of the "github.com/Masterminds/semver/v3" module, e.g. "1.4.x",
+	// "~1.4" or ">= 2.0.0, < 3.0.0".
+	// Note that prerelease versions of tags only satisfy constraints that include a prerelease version, like
+	// "~1.4.0-0".
+	Constraint string
+}
+
+// CIBranch returns the short name of the branch that is built by a CI system, or an empty string when it cannot be
+// determined, e.g. to set the Branch option since CI systems usually check out a detached HEAD.
+// The environment variables of GitHub Actions, GitLab CI/CD, Jenkins, CircleCI, Travis CI, Bitbucket Pipelines and
+// Drone are supported where the source branch of pull and merge requests takes precedence over the target branch.
+func CIBranch() string {
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		if branch := os.Getenv("GITHUB_HEAD_REF"); branch != "" {
+			return branch
+		}
+		if ref := os.Getenv("GITHUB_REF"); strings.HasPrefix(ref, "refs/heads/") {
+			return strings.TrimPrefix(ref, "refs/heads/")
+		}
+		return ""
+	}
+	for _, name := range []string{
+		"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME",
+		"CI_COMMIT_BRANCH",
+		"CHANGE_BRANCH",
+		"BRANCH_NAME",
+		"CIRCLE_BRANCH",
+		"TRAVIS_PULL_REQUEST_BRANCH",
+		"BITBUCKET_BRANCH",
+		"DRONE_SOURCE_BRANCH",
+	} {
+		if branch := os.Getenv(name); branch != "" {
+			return branch
+		}
+	}
+	// Travis CI sets the tag name as branch for builds of tags.
+	if os.Getenv("TRAVIS_TAG") == "" {
+		return os.Getenv("TRAVIS_BRANCH")
+	}
+
+	return ""
+}
+
+// resolveBranchConstraint returns the version constraint of the first rule that matches the given branch, or nil if
+// the branch is empty, because HEAD is detached and the Branch option is not set, or no rule matches.
+// The rules must have been validated before.
+func resolveBranchConstraint(branch string, opt *Options) *semver.Constraints {
+	if branch == "" {
+		return nil
+	}
+	for _, rule := range opt.BranchRules {
+		if matched, _ := path.Match(rule.Branch, branch); matched {
+			constraint, _ := semver.NewConstraint(rule.Constraint)
+			return constraint
+		}
+	}
+
+	return nil
+}
+
+// checkBranchConstraint checks if the release version of the given version, without prerelease version and build
+// metadata, satisfies the given constraint so that prerelease versions of a channel are accepted as well.
+// A nil constraint is always satisfied.
+func checkBranchConstraint(constraint *semver.Constraints, version *semver.Version, branch string) error {
+	if constraint == nil {
+		return nil
+	}
+	release, semVerErr := semver.NewVersion(fmt.Sprintf("%d.%d.%d", version.Major(), version.Minor(), version.Patch()))
+	if semVerErr != nil {
+		return fmt.Errorf("failed to get release version of %s: %w", version, semVerErr)
+	}
+	if !constraint.Check(release) {
+		return newError(ErrVersionConstraint, nil, "version %s does not satisfy constraint %q of branch %s",
+			version, constraint, branch)
+	}
+
+	return nil
+}
+
+// validateBranchRules validates the branch patterns and version constraints of the given options.
+func validateBranchRules(opt *Options) error {
+	for _, rule := range opt.BranchRules {
+		if _, matchErr := path.Match(rule.Branch, ""); matchErr != nil {
+			return newError(ErrInvalidOption, matchErr, "invalid branch pattern %q of branch rule", rule.Branch)
+		}
+		if _, constraintErr := semver.NewConstraint(rule.Constraint); constraintErr != nil {
+			return newError(ErrInvalidOption, constraintErr, "invalid version constraint %q of branch rule for %q",
+				rule.Constraint, rule.Branch)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+// checkout points HEAD to the given branch that is created at the given commit.
+func (tr *testRepository) checkout(branch string, hash plumbing.Hash) {
+	tr.t.Helper()
+	name := plumbing.NewBranchReferenceName(branch)
+	if err := tr.repo.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+		assert.FailNow(tr.t, "failed to create branch", "branch: %q\nerror: %v", branch, err)
+	}
+	if err := tr.repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, name)); err != nil {
+		assert.FailNow(tr.t, "failed to check out branch", "branch: %q\nerror: %v", branch, err)
+	}
+}
+
+func TestDeriveVersion_BranchRules(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.tag("v1.4.0", tr.commit("first"), "v1.4.0")
+	maintenance := tr.commit("fix: maintenance")
+	tr.tag("v1.4.1", maintenance, "v1.4.1")
+	tr.tag("v1.5.0", tr.commit("feat: next"), "v1.5.0")
+	mainline := tr.commit("feat: mainline")
+	tr.checkout("release/1.4", maintenance)
+	head := tr.commit("fix: backport")
+	tr.tag("v2.0.0", head, "v2.0.0")
+
+	rules := glGit.WithBranchRules(
+		glGit.BranchRule{Branch: "release/*", Constraint: "1.4.x"},
+		glGit.BranchRule{Branch: "v2.x", Constraint: "^2"},
+	)
+
+	// The mistakenly created tag outside of the maintenance line is refused.
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, rules)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.4.1+1."+head.String()[:8], version.String())
+
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path, rules, glGit.WithChannel("rc"), glGit.WithHashAbbrevLength(0))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.4.2-rc.1", version.String())
+
+	next, err := glGit.DeriveNextVersion(testDefaultVersion, tr.path, rules)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.4.2", next.Next.String())
+
+	// Branches without a matching rule are not constrained.
+	tr.checkout("master", mainline)
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path, rules)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.5.0+1."+mainline.String()[:8], version.String())
+
+	// The default version is refused when it does not satisfy the constraint.
+	tr.checkout("v2.x", mainline)
+	_, err = glGit.DeriveVersion(testDefaultVersion, tr.path, rules)
+	assert.True(t, errors.Is(err, glGit.ErrVersionConstraint))
+	version, err = glGit.DeriveVersion("2.0.0", tr.path, rules)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0.0", version.String())
+
+	_, err = glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithBranchRules(glGit.BranchRule{Constraint: "invalid"}))
+	assert.True(t, errors.Is(err, glGit.ErrInvalidOption))
+}
+
+func TestDeriveNextVersion_FailWithBranchConstraint(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.tag("v1.4.0", tr.commit("first"), "v1.4.0")
+	tr.checkout("release/1.4", tr.commit("feat: new feature"))
+
+	_, err := glGit.DeriveNextVersion(testDefaultVersion, tr.path,
+		glGit.WithBranchRules(glGit.BranchRule{Branch: "release/*", Constraint: "1.4.x"}),
+	)
+	assert.True(t, errors.Is(err, glGit.ErrVersionConstraint))
+}
+
+func TestDeriveVersion_BranchRulesDetachedHead(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.tag("v1.5.0", tr.commit("first"), "v1.5.0")
+	head := tr.commit("second")
+	if err := tr.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, head)); err != nil {
+		assert.FailNow(t, "failed to detach HEAD", "error: %v", err)
+	}
+	rules := glGit.WithBranchRules(glGit.BranchRule{Branch: "release/*", Constraint: "1.4.x"})
+
+	// No rule is used for a detached HEAD without the branch name...
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, rules)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.5.0+1."+head.String()[:8], version.String())
+	assert.Empty(t, version.Branch)
+
+	// ...while the branch name, e.g. of the CI system, is used to match rules when set.
+	_, err = glGit.DeriveVersion(testDefaultVersion, tr.path, rules, glGit.WithBranch("release/1.4"))
+	assert.True(t, errors.Is(err, glGit.ErrVersionConstraint))
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path,
+		glGit.WithBranch("release/1.4"),
+		glGit.WithChannelRules(glGit.ChannelRule{Branch: "release/*", Channel: "rc"}),
+		glGit.WithHashAbbrevLength(0),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.5.1-rc.1", version.String())
+	assert.Equal(t, "release/1.4", version.Branch)
+	assert.True(t, version.Detached)
+
+	// The branch name is ignored when HEAD is pointing to a branch.
+	tr.checkout("master", head)
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path, rules, glGit.WithBranch("release/1.4"))
+	assert.NoError(t, err)
+	assert.Equal(t, "master", version.Branch)
+}
+
+func TestCIBranch(t *testing.T) {
+	for _, name := range []string{
+		"GITHUB_ACTIONS", "GITHUB_HEAD_REF", "GITHUB_REF", "CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "CI_COMMIT_BRANCH",
+		"CHANGE_BRANCH", "BRANCH_NAME", "CIRCLE_BRANCH", "TRAVIS_PULL_REQUEST_BRANCH", "BITBUCKET_BRANCH",
+		"DRONE_SOURCE_BRANCH", "TRAVIS_TAG", "TRAVIS_BRANCH",
+	} {
+		t.Setenv(name, "")
+	}
+	assert.Empty(t, glGit.CIBranch())
+
+	t.Setenv("CI_COMMIT_BRANCH", "release/1.4")
+	assert.Equal(t, "release/1.4", glGit.CIBranch())
+	t.Setenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "feature/a")
+	assert.Equal(t, "feature/a", glGit.CIBranch())
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_REF", "refs/tags/v1.0.0")
+	assert.Empty(t, glGit.CIBranch())
+	t.Setenv("GITHUB_REF", "refs/heads/main")
+	assert.Equal(t, "main", glGit.CIBranch())
+	t.Setenv("GITHUB_HEAD_REF", "feature/b")
+	assert.Equal(t, "feature/b", glGit.CIBranch())
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+//go:build go1.18
+
+package git
+
+import (
+	"runtime/debug"
+	"strconv"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Keys of the VCS information settings that are embedded in binaries built with Go 1.18 or later.
+const (
+	buildSettingVCS         = "vcs"
+	buildSettingVCSModified = "vcs.modified"
+	buildSettingVCSRevision = "vcs.revision"
+)
+
+// readBuildInfo reconstructs a version from the build information embedded in the running binary.
+// The given default version is used when the module version is not a version of the CalVerScheme with the given
+// format, or a SemVer version when the format is empty, like "(devel)".
+func readBuildInfo(defaultVersion, scheme string) (*Version, error) {
+	vd := &versionData{Scheme: scheme, Version: defaultVersion}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return newBuildInfoVersion(vd)
+	}
+
+	if isVersion(info.Main.Version, scheme) {
+		vd.Version = info.Main.Version
+	}
+	settings := make(map[string]string, len(info.Settings))
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	if settings[buildSettingVCS] == "git" {
+		if revision := settings[buildSettingVCSRevision]; plumbing.IsHash(revision) {
+			vd.CommitHash = revision
+		}
+		vd.Dirty, _ = strconv.ParseBool(settings[buildSettingVCSModified])
+	}
+
+	return newBuildInfoVersion(vd)
+}
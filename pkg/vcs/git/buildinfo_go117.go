@@ -0,0 +1,21 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+//go:build !go1.18
+
+package git
+
+import "runtime/debug"
+
+// readBuildInfo reconstructs a version from the build information embedded in the running binary.
+// Before Go 1.18 no VCS information is embedded, so only the module version is used and the given default version when
+// it is not a version of the CalVerScheme with the given format, or a SemVer version when the format is empty, like
+// "(devel)".
+func readBuildInfo(defaultVersion, scheme string) (*Version, error) {
+	vd := &versionData{Scheme: scheme, Version: defaultVersion}
+	if info, ok := debug.ReadBuildInfo(); ok && isVersion(info.Main.Version, scheme) {
+		vd.Version = info.Main.Version
+	}
+
+	return newBuildInfoVersion(vd)
+}
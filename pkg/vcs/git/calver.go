@@ -0,0 +1,249 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/svengreb/golib/pkg/vcs/git/conventional"
+)
+
+// calVerToken is a version segment token of a CalVer format.
+type calVerToken struct {
+	// max is the maximum value of the segment, or 0 if there is none.
+	max uint64
+
+	// min is the minimum value of the segment.
+	min uint64
+
+	// name is the name of the token.
+	name string
+
+	// padded indicates whether the value of the segment is zero-padded to two digits.
+	padded bool
+
+	// value returns the value of the segment for the given time, or nil for the MICRO token that is incremented instead.
+	// The ISO 8601 week-numbering year is used instead of the calendar year when isoYear is true.
+	value func(t time.Time, isoYear bool) (uint64, error)
+}
+
+// calVerTokens are all supported CalVer format tokens.
+var calVerTokens = map[string]*calVerToken{
+	"YYYY":  {name: "YYYY", min: 1000, max: 9999, value: year},
+	"YY":    {name: "YY", value: shortYear},
+	"0Y":    {name: "0Y", padded: true, value: shortYear},
+	"MM":    {name: "MM", min: 1, max: 12, value: month},
+	"0M":    {name: "0M", min: 1, max: 12, padded: true, value: month},
+	"WW":    {name: "WW", min: 1, max: 53, value: isoWeek},
+	"0W":    {name: "0W", min: 1, max: 53, padded: true, value: isoWeek},
+	"DD":    {name: "DD", min: 1, max: 31, value: day},
+	"0D":    {name: "0D", min: 1, max: 31, padded: true, value: day},
+	"MICRO": {name: "MICRO"},
+}
+
+// CalVerScheme is the Scheme for "Calendar Versioning" with a configurable format.
+// The next version consists of the date of the release and a MICRO version that is reset to 0 for every new date and
+// incremented for every release with the same date, regardless of the version bump.
+//
+// See https://calver.org for more details.
+type CalVerScheme struct {
+	format string
+	tokens []*calVerToken
+
+	// weekBased indicates whether the format has a week token so that the ISO 8601 week-numbering year is used.
+	weekBased bool
+}
+
+// NewCalVerScheme creates a new CalVer scheme for the given format.
+// The format consists of one to three dot-separated tokens, like "YYYY.0M.MICRO" for versions like "2026.01.3" or
+// "YY.MM" for versions like "26.1":
+//   - "YYYY" is the full year, e.g. 2006 or 2026.
+//   - "YY" and "0Y" are the short year, the full year minus 2000, e.g. 6 or 26, "0Y" zero-padded to 06 and 26, that
+//     is not available for years before 2000.
+//   - "MM" and "0M" are the month, e.g. 1 or 12, "0M" zero-padded to 01 and 12.
+//   - "WW" and "0W" are the ISO 8601 week of the year, e.g. 1 or 52, "0W" zero-padded to 01 and 52.
+//   - "DD" and "0D" are the day of the month, e.g. 1 or 31, "0D" zero-padded to 01 and 31.
+//   - "MICRO" is the incrementing number of releases with the same date that must be the last token.
+//
+// At least one date token is required. When the format has a week token, the year tokens are the ISO 8601
+// week-numbering year so that e.g. the 30th of December 2024 in the first week of 2025 is versioned as "2025.1.0"
+// instead of "2024.1.0".
+func NewCalVerScheme(format string) (*CalVerScheme, error) {
+	names := strings.Split(format, ".")
+	if len(names) > 3 {
+		return nil, newError(ErrInvalidOption, nil, "CalVer format %q must not have more than 3 segments", format)
+	}
+
+	scheme := &CalVerScheme{format: format}
+	for i, name := range names {
+		token, ok := calVerTokens[name]
+		if !ok {
+			return nil, newError(ErrInvalidOption, nil, "unknown token %q in CalVer format %q", name, format)
+		}
+		if token.value == nil && i != len(names)-1 {
+			return nil, newError(ErrInvalidOption, nil, "MICRO must be the last token of CalVer format %q", format)
+		}
+		scheme.tokens = append(scheme.tokens, token)
+		scheme.weekBased = scheme.weekBased || name == "WW" || name == "0W"
+	}
+	if len(names) == 1 && scheme.tokens[0].value == nil {
+		return nil, newError(ErrInvalidOption, nil, "CalVer format %q must have at least one date token", format)
+	}
+
+	return scheme, nil
+}
+
+// Format returns the given version in the format of the scheme.
+func (s *CalVerScheme) Format(version *semver.Version) string {
+	values := []uint64{version.Major(), version.Minor(), version.Patch()}
+	segments := make([]string, len(s.tokens))
+	for i, token := range s.tokens {
+		segments[i] = strconv.FormatUint(values[i], 10)
+		if token.padded && values[i] < 10 {
+			segments[i] = "0" + segments[i]
+		}
+	}
+
+	formatted := strings.Join(segments, ".")
+	if version.Prerelease() != "" {
+		formatted += "-" + version.Prerelease()
+	}
+	if version.Metadata() != "" {
+		formatted += "+" + version.Metadata()
+	}
+
+	return formatted
+}
+
+// Next returns the version for the date of the given time when it is later than the date of the given current version,
+// otherwise the current version with an incremented MICRO version.
+// An error is returned when the format has no MICRO token and the date of the current version is not earlier than the
+// date of the given time.
+// The given version bump is ignored because the version only depends on the date.
+func (s *CalVerScheme) Next(current *semver.Version, _ conventional.Bump, now time.Time) (*semver.Version, error) {
+	values := make([]uint64, 3)
+	for i, token := range s.tokens {
+		if token.value == nil {
+			continue
+		}
+		value, valueErr := token.value(now, s.weekBased)
+		if valueErr != nil {
+			return nil, newError(ErrInvalidVersion, valueErr, "failed to compute token %s of CalVer format %q", token.name,
+				s.format)
+		}
+		values[i] = value
+	}
+
+	if current != nil {
+		currentValues := []uint64{current.Major(), current.Minor(), current.Patch()}
+		if !s.isLaterDate(values, currentValues) {
+			last := len(s.tokens) - 1
+			if s.tokens[last].value != nil {
+				return nil, newError(ErrInvalidVersion, nil, "version %s has already been released for the date of %s",
+					s.Format(current), now.Format("2006-01-02"))
+			}
+			copy(values, currentValues)
+			values[last]++
+		}
+	}
+
+	return semver.NewVersion(fmt.Sprintf("%d.%d.%d", values[0], values[1], values[2]))
+}
+
+// Parse parses the given version that must match the format of the scheme.
+// The version can include a prerelease version and build metadata like a SemVer version.
+func (s *CalVerScheme) Parse(version string) (*semver.Version, error) {
+	core := version
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	segments := strings.Split(core, ".")
+	if len(segments) != len(s.tokens) {
+		return nil, newError(ErrInvalidVersion, nil, "version %q does not match CalVer format %q", version, s.format)
+	}
+	for i, segment := range segments {
+		if !s.tokens[i].matches(segment) {
+			return nil, newError(ErrInvalidVersion, nil, "segment %q of version %q does not match token %s of CalVer format %q",
+				segment, version, s.tokens[i].name, s.format)
+		}
+	}
+
+	semVersion, semVerErr := semver.NewVersion(version)
+	if semVerErr != nil {
+		return nil, newError(ErrInvalidVersion, semVerErr, "failed to parse CalVer version %q", version)
+	}
+
+	return semVersion, nil
+}
+
+// String returns the format of the scheme.
+func (s *CalVerScheme) String() string {
+	return s.format
+}
+
+// isLaterDate checks if the date segments of the given values are later than those of the given current values.
+func (s *CalVerScheme) isLaterDate(values, currentValues []uint64) bool {
+	for i, token := range s.tokens {
+		if token.value == nil || values[i] == currentValues[i] {
+			continue
+		}
+		return values[i] > currentValues[i]
+	}
+
+	return false
+}
+
+// matches checks if the given version segment matches the token.
+func (t *calVerToken) matches(segment string) bool {
+	value, parseErr := strconv.ParseUint(segment, 10, 64)
+	if parseErr != nil || value < t.min || (t.max > 0 && value > t.max) {
+		return false
+	}
+	if t.padded {
+		return len(segment) >= 2 && (len(segment) == 2 || segment[0] != '0')
+	}
+
+	return segment == strconv.FormatUint(value, 10)
+}
+
+// year returns the full year of the given time, or the ISO 8601 week-numbering year when isoYear is true.
+func year(t time.Time, isoYear bool) (uint64, error) {
+	if isoYear {
+		y, _ := t.ISOWeek()
+		return uint64(y), nil
+	}
+
+	return uint64(t.Year()), nil
+}
+
+// shortYear returns the full year of the given time minus 2000, or an error for years before 2000.
+func shortYear(t time.Time, isoYear bool) (uint64, error) {
+	y, _ := year(t, isoYear)
+	if y < 2000 {
+		return 0, fmt.Errorf("short year is not available for year %d before 2000", y)
+	}
+
+	return y - 2000, nil
+}
+
+// month returns the month of the given time.
+func month(t time.Time, _ bool) (uint64, error) {
+	return uint64(t.Month()), nil
+}
+
+// isoWeek returns the ISO 8601 week of the year of the given time.
+func isoWeek(t time.Time, _ bool) (uint64, error) {
+	_, week := t.ISOWeek()
+	return uint64(week), nil
+}
+
+// day returns the day of the month of the given time.
+func day(t time.Time, _ bool) (uint64, error) {
+	return uint64(t.Day()), nil
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+	"github.com/svengreb/golib/pkg/vcs/git/conventional"
+)
+
+// newTestCalVerScheme creates a new CalVer scheme for the given format.
+func newTestCalVerScheme(t *testing.T, format string) *glGit.CalVerScheme {
+	t.Helper()
+	scheme, err := glGit.NewCalVerScheme(format)
+	if err != nil {
+		assert.FailNow(t, "failed to create CalVer scheme", "format: %q\nerror: %v", format, err)
+	}
+
+	return scheme
+}
+
+func TestNewCalVerScheme_FailWithInvalidFormat(t *testing.T) {
+	for _, format := range []string{"", "YYYY.MM.DD.MICRO", "YYYY.MONTH", "MICRO.YYYY", "MICRO"} {
+		_, err := glGit.NewCalVerScheme(format)
+		assert.True(t, errors.Is(err, glGit.ErrInvalidOption), "format: %q", format)
+	}
+}
+
+func TestCalVerScheme_Parse(t *testing.T) {
+	testCases := []struct {
+		format  string
+		valid   []string
+		invalid []string
+	}{
+		{
+			format:  "YYYY.0M.MICRO",
+			valid:   []string{"2026.10.1", "2026.01.0", "2026.01.0-rc.1", "2026.01.0+build"},
+			invalid: []string{"2026.1.0", "2026.13.0", "26.01.0", "2026.01", "2026.01.01", "v2026.01.0"},
+		},
+		{
+			format:  "YY.MM",
+			valid:   []string{"26.10", "6.1", "106.12"},
+			invalid: []string{"26.01", "06.1", "26.0", "26.10.1"},
+		},
+		{
+			format:  "0Y.0W.DD",
+			valid:   []string{"06.52.1", "26.01.31", "106.53.9"},
+			invalid: []string{"6.52.1", "26.54.1", "26.01.32", "26.01.09"},
+		},
+	}
+
+	for _, tc := range testCases {
+		scheme := newTestCalVerScheme(t, tc.format)
+		for _, v := range tc.valid {
+			version, err := scheme.Parse(v)
+			if assert.NoError(t, err, "format: %q\nversion: %q", tc.format, v) {
+				assert.Equal(t, v, scheme.Format(version))
+			}
+		}
+		for _, v := range tc.invalid {
+			_, err := scheme.Parse(v)
+			assert.True(t, errors.Is(err, glGit.ErrInvalidVersion), "format: %q\nversion: %q", tc.format, v)
+		}
+	}
+}
+
+func TestCalVerScheme_Next(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		current  string
+		expected string
+		format   string
+	}{
+		{format: "YYYY.0M.MICRO", expected: "2026.10.0"},
+		{format: "YYYY.0M.MICRO", current: "2026.09.4", expected: "2026.10.0"},
+		{format: "YYYY.0M.MICRO", current: "2026.10.4", expected: "2026.10.5"},
+		{format: "YYYY.0M.MICRO", current: "2026.10.4-rc.1", expected: "2026.10.5"},
+		{format: "YYYY.0M.MICRO", current: "2026.11.0", expected: "2026.11.1"},
+		{format: "YY.MM", current: "26.9", expected: "26.10"},
+		{format: "0Y.0M.0D", current: "25.12.31", expected: "26.10.18"},
+		{format: "YYYY.WW.MICRO", current: "2026.42.0", expected: "2026.42.1"},
+	}
+
+	for _, tc := range testCases {
+		scheme := newTestCalVerScheme(t, tc.format)
+		var current *semver.Version
+		if tc.current != "" {
+			var err error
+			if current, err = scheme.Parse(tc.current); err != nil {
+				assert.FailNow(t, "failed to parse current version", "version: %q\nerror: %v", tc.current, err)
+			}
+		}
+
+		next, err := scheme.Next(current, conventional.BumpMajor, now)
+		if assert.NoError(t, err, "format: %q\ncurrent: %q", tc.format, tc.current) {
+			assert.Equal(t, tc.expected, scheme.Format(next))
+		}
+	}
+
+	scheme := newTestCalVerScheme(t, "YY.MM")
+	_, err := scheme.Next(semver.MustParse("26.10"), conventional.BumpPatch, now)
+	assert.True(t, errors.Is(err, glGit.ErrInvalidVersion))
+	_, err = scheme.Next(nil, conventional.BumpPatch, time.Date(1999, 12, 31, 12, 0, 0, 0, time.UTC))
+	assert.True(t, errors.Is(err, glGit.ErrInvalidVersion))
+}
+
+func TestCalVerScheme_NextWeekAroundNewYear(t *testing.T) {
+	for _, tc := range []struct {
+		expected string
+		format   string
+		now      time.Time
+	}{
+		{format: "YYYY.WW.MICRO", now: time.Date(2024, 12, 30, 12, 0, 0, 0, time.UTC), expected: "2025.1.0"},
+		{format: "0Y.0W", now: time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC), expected: "20.53"},
+		{format: "YYYY.WW.MICRO", now: time.Date(2026, 12, 31, 12, 0, 0, 0, time.UTC), expected: "2026.53.0"},
+		{format: "YYYY.MM.MICRO", now: time.Date(2024, 12, 30, 12, 0, 0, 0, time.UTC), expected: "2024.12.0"},
+	} {
+		next, err := newTestCalVerScheme(t, tc.format).Next(nil, conventional.BumpPatch, tc.now)
+		if assert.NoError(t, err, "format: %q\ntime: %s", tc.format, tc.now) {
+			assert.Equal(t, tc.expected, newTestCalVerScheme(t, tc.format).Format(next))
+		}
+	}
+}
+
+func TestSemVerScheme_Next(t *testing.T) {
+	scheme := glGit.SemVerScheme{}
+	current := semver.MustParse("1.2.3")
+
+	for bump, expected := range map[conventional.Bump]string{
+		conventional.BumpNone:  "1.2.3",
+		conventional.BumpPatch: "1.2.4",
+		conventional.BumpMinor: "1.3.0",
+		conventional.BumpMajor: "2.0.0",
+	} {
+		next, err := scheme.Next(current, bump, time.Time{})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, next.String(), "bump: %s", bump)
+	}
+}
+
+func TestDeriveVersion_CalVerScheme(t *testing.T) {
+	tr := newTestRepository(t)
+	first := tr.commit("first")
+	tr.tag("v1.0.0", first, "v1.0.0")
+	tr.tag("2026.01.0", first, "2026.01.0")
+	tr.tag("2026.1.1", first, "2026.1.1")
+	head := tr.commit("feat: second")
+
+	scheme := newTestCalVerScheme(t, "YYYY.0M.MICRO")
+	version, err := glGit.DeriveVersion("2026.01.0", tr.path, glGit.WithScheme(scheme))
+	assert.NoError(t, err)
+	assert.Equal(t, "2026.01.0+1."+head.String()[:8], version.String())
+	assert.Equal(t, "refs/tags/2026.01.0", version.LatestVersionTag.Name().String())
+
+	_, err = glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithScheme(scheme))
+	assert.True(t, errors.Is(err, glGit.ErrInvalidDefaultVersion))
+
+	next, err := glGit.DeriveNextVersion("2026.01.0", tr.path, glGit.WithScheme(scheme))
+	assert.NoError(t, err)
+	assert.Equal(t, scheme.Format(next.Next), next.String())
+	assert.True(t, next.Next.GreaterThan(semver.MustParse("2026.1.0")))
+
+	_, err = glGit.CreateVersionTag(tr.path, "2026.1.2", "", nil, glGit.WithScheme(scheme))
+	assert.True(t, errors.Is(err, glGit.ErrInvalidVersion))
+	ref, err := glGit.CreateVersionTag(tr.path, "2026.01.1", "", nil, glGit.WithScheme(scheme))
+	assert.NoError(t, err)
+	assert.Equal(t, "2026.01.1", ref.Name().Short())
+}
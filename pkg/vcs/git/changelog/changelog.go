@@ -0,0 +1,222 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+// Package changelog provides functions to generate changelogs from the history of Git repositories whose commit
+// messages follow the "Conventional Commits" specification.
+// See https://www.conventionalcommits.org for more details about "Conventional Commits".
+//
+// Release notes are rendered through formats based on the "text/template" Go standard library package, including the
+// "Keep a Changelog" format (https://keepachangelog.com) and the format used for the changelog of this project, and can
+// be inserted into existing changelog files without changing any other content.
+package changelog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+	"github.com/svengreb/golib/pkg/vcs/git/conventional"
+)
+
+// ShortHashLength is the amount of digits commit hashes are shortened to.
+const ShortHashLength = 8
+
+// referenceFooterTokens are the lowercase footer tokens whose values are references to issues or pull requests.
+var referenceFooterTokens = map[string]bool{
+	"closes":   true,
+	"fixes":    true,
+	"refs":     true,
+	"resolves": true,
+}
+
+// Entry is a changelog entry derived from a commit whose message follows the "Conventional Commits" specification.
+type Entry struct {
+	// Body is the body of the commit message.
+	Body string
+
+	// Breaking indicates whether the commit introduces a breaking change.
+	Breaking bool
+
+	// BreakingChange is the description of the breaking change.
+	BreakingChange string
+
+	// Description is the description of the commit message header.
+	Description string
+
+	// Hash is the hash of the commit.
+	Hash plumbing.Hash
+
+	// References are the references to issues or pull requests from the "Closes", "Fixes", "Refs" and "Resolves"
+	// footers of the commit message, e.g. "#42".
+	References []string
+
+	// Scope is the scope of the commit message header.
+	Scope string
+
+	// Type is the type of the commit message header.
+	Type string
+}
+
+// ShortHash returns the shortened hash of the commit.
+func (e *Entry) ShortHash() string {
+	return e.Hash.String()[:ShortHashLength]
+}
+
+// Release stores the changes of a release.
+type Release struct {
+	// CompareURL is the URL to compare the history of the release, or empty if not configured.
+	CompareURL string
+
+	// Date is the date of the release.
+	Date time.Time
+
+	// Entries are the changelog entries of the release, ordered by committer time, newest first.
+	Entries []*Entry
+
+	// From is the revision the history of the release starts from, exclusive, or empty if the release includes the whole
+	// history.
+	From string
+
+	// To is the revision the history of the release ends with, inclusive.
+	To string
+
+	// Version is the version of the release.
+	Version string
+}
+
+// Group is a group of changelog entries with the same kind of change.
+type Group struct {
+	// Entries are the changelog entries of the group.
+	Entries []*Entry
+
+	// Title is the title of the group.
+	Title string
+}
+
+// GroupRule is a rule to group changelog entries by the types of their commit messages.
+type GroupRule struct {
+	// Title is the title of the group.
+	Title string
+
+	// Types are the commit message types of entries that belong to the group.
+	Types []string
+}
+
+// Group groups the changelog entries of the release by the given rules.
+// Each entry is added to the group of the first matching rule while entries that match no rule are omitted. The groups
+// are returned in the order of the rules without empty groups.
+func (r *Release) Group(rules []GroupRule) []*Group {
+	groups := make([]*Group, len(rules))
+	for i, rule := range rules {
+		groups[i] = &Group{Title: rule.Title}
+	}
+
+	for _, entry := range r.Entries {
+	rules:
+		for i, rule := range rules {
+			for _, t := range rule.Types {
+				if t == entry.Type {
+					groups[i].Entries = append(groups[i].Entries, entry)
+					break rules
+				}
+			}
+		}
+	}
+
+	var nonEmpty []*Group
+	for _, g := range groups {
+		if len(g.Entries) > 0 {
+			nonEmpty = append(nonEmpty, g)
+		}
+	}
+
+	return nonEmpty
+}
+
+// Generate generates the changelog of a release from all commits of a Git repository that are reachable from the "to"
+// revision, but not from the "from" revision, whose messages follow the "Conventional Commits" specification.
+// All commits reachable from the "to" revision are included when "from" is empty while "to" defaults to HEAD when
+// empty.
+// The version of the release is parsed from the "to" revision when it is a SemVer compatible tag name, otherwise it must
+// be set through the Version option.
+func Generate(repositoryPath, from, to string, opts ...Option) (*Release, error) {
+	opt := NewOptions(opts...)
+
+	if to == "" {
+		to = plumbing.HEAD.String()
+	}
+	version := opt.Version
+	if version == "" {
+		semVersion, semVerErr := semver.NewVersion(strings.TrimPrefix(to, "refs/tags/"))
+		if semVerErr != nil {
+			return nil, fmt.Errorf("version must be set when revision %q is not a version tag: %w", to, semVerErr)
+		}
+		version = semVersion.String()
+	}
+
+	commits, commitsErr := glGit.CommitsBetween(repositoryPath, from, to)
+	if commitsErr != nil {
+		return nil, fmt.Errorf("failed to get commits between %q and %q: %w", from, to, commitsErr)
+	}
+
+	r := &Release{Date: opt.Date, From: from, To: to, Version: version}
+	for _, c := range commits {
+		msg, parseErr := conventional.Parse(c.Message)
+		if parseErr != nil {
+			continue
+		}
+		r.Entries = append(r.Entries, &Entry{
+			Body:           msg.Body,
+			Breaking:       msg.Breaking,
+			BreakingChange: msg.BreakingChange(),
+			Description:    msg.Description,
+			Hash:           c.Hash,
+			References:     references(msg),
+			Scope:          msg.Scope,
+			Type:           msg.Type,
+		})
+	}
+
+	if r.Date.IsZero() {
+		r.Date = time.Now()
+		if len(commits) > 0 {
+			r.Date = commits[0].Committer.When
+		}
+	}
+
+	if opt.CompareURLFormat != "" {
+		compareFrom := from
+		if compareFrom == "" && len(commits) > 0 {
+			compareFrom = commits[len(commits)-1].Hash.String()[:ShortHashLength]
+		}
+		compareTo := to
+		if compareTo == plumbing.HEAD.String() {
+			compareTo = opt.TagPrefix + version
+		}
+		r.CompareURL = fmt.Sprintf(opt.CompareURLFormat, compareFrom, compareTo)
+	}
+
+	return r, nil
+}
+
+// references returns the references to issues or pull requests from the footers of the given commit message.
+func references(msg *conventional.Commit) []string {
+	var refs []string
+	for _, footer := range msg.Footers {
+		if !referenceFooterTokens[strings.ToLower(footer.Token)] {
+			continue
+		}
+		for _, ref := range strings.FieldsFunc(footer.Value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if strings.Trim(ref, "0123456789") == "" {
+				ref = "#" + ref
+			}
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
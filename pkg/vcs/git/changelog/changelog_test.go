@@ -0,0 +1,206 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package changelog_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/svengreb/golib/pkg/vcs/git/changelog"
+)
+
+// newTestRepository creates a repository in a temporary directory with a commit for each of the given messages and
+// returns its path and the commit hashes.
+func newTestRepository(t *testing.T, messages ...string) (string, []plumbing.Hash) {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		assert.FailNow(t, "failed to initialize repository", "path: %q\nerror: %v", dir, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		assert.FailNow(t, "failed to get worktree", "error: %v", err)
+	}
+
+	hashes := make([]plumbing.Hash, 0, len(messages))
+	for i, msg := range messages {
+		name := fmt.Sprintf("file-%d", i)
+		if err = os.WriteFile(filepath.Join(dir, name), []byte(msg), 0o600); err != nil {
+			assert.FailNow(t, "failed to write file", "file: %q\nerror: %v", name, err)
+		}
+		if _, err = wt.Add(name); err != nil {
+			assert.FailNow(t, "failed to add file", "file: %q\nerror: %v", name, err)
+		}
+		hash, commitErr := wt.Commit(msg, &git.CommitOptions{Author: &object.Signature{
+			Name:  "Sven Greb",
+			Email: "development@svengreb.de",
+			When:  time.Date(2020, 11, 21, 12, i, 0, 0, time.UTC),
+		}})
+		if commitErr != nil {
+			assert.FailNow(t, "failed to commit", "message: %q\nerror: %v", msg, commitErr)
+		}
+		hashes = append(hashes, hash)
+	}
+	if _, err = repo.CreateTag("v0.1.0", hashes[0], nil); err != nil {
+		assert.FailNow(t, "failed to create tag", "error: %v", err)
+	}
+
+	return dir, hashes
+}
+
+func TestGenerate(t *testing.T) {
+	dir, hashes := newTestRepository(t,
+		"feat: initial release",
+		"feat(vcs/git): add changelog generation\n\nRelease notes are generated from the history.\n\nCloses #8\nRefs: #9",
+		"docs: update documentation",
+		"fix!: fix bug\n\nBREAKING CHANGE: behavior changed",
+		"Merge branch 'feature'",
+	)
+
+	r, err := changelog.Generate(dir, "v0.1.0", "", changelog.WithVersion("0.2.0"),
+		changelog.WithCompareURLFormat("https://github.com/svengreb/golib/compare/%s...%s"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "0.2.0", r.Version)
+	assert.Equal(t, "https://github.com/svengreb/golib/compare/v0.1.0...v0.2.0", r.CompareURL)
+	assert.Equal(t, time.Date(2020, 11, 21, 12, 4, 0, 0, time.UTC), r.Date.UTC())
+	if assert.Len(t, r.Entries, 3) {
+		assert.Equal(t, hashes[3], r.Entries[0].Hash)
+		assert.True(t, r.Entries[0].Breaking)
+		assert.Equal(t, "behavior changed", r.Entries[0].BreakingChange)
+		assert.Equal(t, []string{"#8", "#9"}, r.Entries[2].References)
+		assert.Equal(t, "vcs/git", r.Entries[2].Scope)
+	}
+
+	_, err = changelog.Generate(dir, "v0.1.0", "HEAD")
+	assert.Error(t, err)
+
+	r, err = changelog.Generate(dir, "", "v0.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.1.0", r.Version)
+	assert.Len(t, r.Entries, 1)
+}
+
+func TestFormat_Render(t *testing.T) {
+	dir, hashes := newTestRepository(t,
+		"feat: initial release",
+		"feat(vcs/git): add changelog generation\n\nRelease notes are generated from the history.\n\nCloses #8",
+		"fix: fix bug",
+	)
+	r, err := changelog.Generate(dir, "v0.1.0", "", changelog.WithVersion("0.2.0"),
+		changelog.WithCompareURLFormat("https://github.com/svengreb/golib/compare/%s...%s"))
+	if err != nil {
+		assert.FailNow(t, "failed to generate changelog", "error: %v", err)
+	}
+
+	section, err := changelog.FormatKeepAChangelog.Render(r)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf(`## [0.2.0] - 2020-11-21
+
+### Added
+
+- **vcs/git:** add changelog generation (#8) (%s)
+
+### Fixed
+
+- fix bug (%s)
+`, hashes[1].String()[:8], hashes[2].String()[:8]), section.Content)
+	assert.Equal(t, "[0.2.0]: https://github.com/svengreb/golib/compare/v0.1.0...v0.2.0\n", section.Links)
+
+	section, err = changelog.FormatDetails.Render(r)
+	assert.NoError(t, err)
+	assert.Contains(t, section.Content, "# 0.2.0\n")
+	assert.Contains(t, section.Content, "⇅ [Show all commits][repo-compare-tag-v0.1.0_v0.2.0]")
+	assert.Contains(t, section.Content, fmt.Sprintf(
+		"<summary><strong>add changelog generation</strong> — #8 (⊶ %s)</summary>\n\n↠ Release notes are generated from the history.\n",
+		hashes[1].String()[:8]))
+	assert.Equal(t,
+		"<!-- v0.2.0 -->\n[repo-compare-tag-v0.1.0_v0.2.0]: https://github.com/svengreb/golib/compare/v0.1.0...v0.2.0\n",
+		section.Links)
+}
+
+func TestFormat_Insert(t *testing.T) {
+	existing := `# Changelog
+
+Hand-written introduction.
+
+## [Unreleased]
+
+- Hand-written entry.
+
+## [0.1.0] - 2020-11-21
+
+### Added
+
+- initial release
+
+[unreleased]: https://github.com/svengreb/golib/compare/v0.1.0...HEAD
+[0.1.0]: https://github.com/svengreb/golib/releases/tag/v0.1.0
+`
+	section := &changelog.Section{
+		Content: "## [0.2.0] - 2020-11-22\n\n### Fixed\n\n- fix bug\n",
+		Links:   "[0.2.0]: https://github.com/svengreb/golib/compare/v0.1.0...v0.2.0\n",
+	}
+
+	updated, err := changelog.FormatKeepAChangelog.Insert([]byte(existing), section)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `# Changelog
+
+Hand-written introduction.
+
+## [Unreleased]
+
+- Hand-written entry.
+
+## [0.2.0] - 2020-11-22
+
+### Fixed
+
+- fix bug
+
+## [0.1.0] - 2020-11-21
+
+### Added
+
+- initial release
+
+[unreleased]: https://github.com/svengreb/golib/compare/v0.1.0...HEAD
+[0.2.0]: https://github.com/svengreb/golib/compare/v0.1.0...v0.2.0
+[0.1.0]: https://github.com/svengreb/golib/releases/tag/v0.1.0
+`, string(updated))
+
+	section.Content = "## [0.1.0] - 2020-11-23\n"
+	_, err = changelog.FormatKeepAChangelog.Insert([]byte(existing), section)
+	assert.True(t, errors.Is(err, changelog.ErrSectionExists))
+}
+
+func TestFormat_PrependFile(t *testing.T) {
+	dir, _ := newTestRepository(t, "feat: initial release", "fix: fix bug")
+	r, err := changelog.Generate(dir, "v0.1.0", "", changelog.WithVersion("0.2.0"))
+	if err != nil {
+		assert.FailNow(t, "failed to generate changelog", "error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	if err = os.WriteFile(path, []byte("<!-- Hand-written header -->\n"), 0o600); err != nil {
+		assert.FailNow(t, "failed to write changelog", "error: %v", err)
+	}
+
+	assert.NoError(t, changelog.FormatDetails.PrependFile(path, r))
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Regexp(t, "^<!-- Hand-written header -->\n\n# 0.2.0\n", string(content))
+
+	assert.Error(t, changelog.FormatDetails.PrependFile(path, r))
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package changelog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/svengreb/golib/pkg/vcs/git/conventional"
+)
+
+const (
+	// templateNameLinks is the name of the optional template that renders the link reference definitions of a release.
+	templateNameLinks = "links"
+
+	// templateNameSection is the name of the template that renders the section of a release.
+	templateNameSection = "section"
+)
+
+// ErrSectionExists is the error returned when a changelog already contains a section with the same heading.
+var ErrSectionExists = errors.New("changelog already contains a section with the same heading")
+
+// templateFuncs are the functions available in format templates.
+var templateFuncs = template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("2006-01-02") },
+	"join": strings.Join,
+}
+
+// Format is a changelog format based on templates of the "text/template" Go standard library package.
+type Format struct {
+	// GroupRules are the rules to group the changelog entries of a release.
+	GroupRules []GroupRule
+
+	// LinksHeading matches the line before which new link reference definitions are inserted into an existing changelog.
+	// When nil or not matching any line, new link reference definitions are appended to the end.
+	LinksHeading *regexp.Regexp
+
+	// SectionHeading matches the heading lines of release sections.
+	// New sections are inserted before the first matching line of an existing changelog, or appended to the end when no
+	// line matches.
+	SectionHeading *regexp.Regexp
+
+	tmpl *template.Template
+}
+
+// Section is a rendered release section.
+type Section struct {
+	// Content is the content of the section.
+	Content string
+
+	// Links are the link reference definitions used in the content.
+	Links string
+}
+
+// sectionData is the data passed to format templates.
+type sectionData struct {
+	*Release
+
+	// Groups are the grouped changelog entries of the release.
+	Groups []*Group
+}
+
+var (
+	// FormatDetails is the format used for the changelog of this project where each entry is rendered as collapsible
+	// "details" HTML element within groups of features, improvements and bug fixes.
+	// See https://github.com/svengreb/golib/blob/main/CHANGELOG.md for an example.
+	FormatDetails = MustNewFormat(
+		`{{define "section"}}# {{.Version}}
+
+![Release Date: {{date .Date}}](https://img.shields.io/static/v1?style=flat-square&label=Release%20Date&message={{date .Date}}&colorA=4c566a&colorB=88c0d0)
+{{if .CompareURL}}
+⇅ [Show all commits][repo-compare-tag-{{or .From "init"}}_v{{.Version}}]
+{{end}}{{range .Groups}}
+## {{.Title}}
+{{range .Entries}}
+<details>
+<summary><strong>{{.Description}}</strong> — {{with .References}}{{join . " ⇄ "}} {{end}}(⊶ {{.ShortHash}})</summary>
+{{with .Body}}
+↠ {{.}}
+{{end}}
+</details>
+{{end}}{{end}}{{end}}
+{{- define "links"}}{{if .CompareURL}}<!-- v{{.Version}} -->
+[repo-compare-tag-{{or .From "init"}}_v{{.Version}}]: {{.CompareURL}}
+{{end}}{{end}}`,
+		[]GroupRule{
+			{Title: "Features", Types: []string{conventional.TypeFeat}},
+			{Title: "Improvements", Types: []string{conventional.TypePerf, "refactor"}},
+			{Title: "Bug Fixes", Types: []string{conventional.TypeFix}},
+		},
+		regexp.MustCompile(`^# v?\d`),
+		regexp.MustCompile(`^<!-- v\d`),
+	)
+
+	// FormatKeepAChangelog is the "Keep a Changelog" format.
+	// New releases are inserted after the "Unreleased" section which must be maintained manually.
+	// See https://keepachangelog.com for more details.
+	FormatKeepAChangelog = MustNewFormat(
+		`{{define "section"}}## [{{.Version}}] - {{date .Date}}
+{{range .Groups}}
+### {{.Title}}
+
+{{range .Entries}}- {{if .Breaking}}**BREAKING:** {{end}}{{with .Scope}}**{{.}}:** {{end}}{{.Description}}{{with .References}} ({{join . ", "}}){{end}} ({{.ShortHash}})
+{{end}}{{end}}{{end}}
+{{- define "links"}}{{if .CompareURL}}[{{.Version}}]: {{.CompareURL}}
+{{end}}{{end}}`,
+		[]GroupRule{
+			{Title: "Added", Types: []string{conventional.TypeFeat}},
+			{Title: "Changed", Types: []string{conventional.TypePerf, "refactor"}},
+			{Title: "Fixed", Types: []string{conventional.TypeFix}},
+		},
+		regexp.MustCompile(`^## \[v?\d`),
+		regexp.MustCompile(`^\[v?\d[^\]]*\]: `),
+	)
+)
+
+// NewFormat creates a new changelog format from the given template text that must define a template named "section"
+// and can optionally define a template named "links" for link reference definitions.
+// Both templates are executed with the release as data that additionally provides the grouped changelog entries
+// through the "Groups" field. The "date" function formats a time as "YYYY-MM-DD" and the "join" function joins strings.
+func NewFormat(text string, groupRules []GroupRule, sectionHeading, linksHeading *regexp.Regexp) (*Format, error) {
+	tmpl, parseErr := template.New("changelog").Funcs(templateFuncs).Parse(text)
+	if parseErr != nil {
+		return nil, fmt.Errorf("failed to parse format template: %w", parseErr)
+	}
+	if tmpl.Lookup(templateNameSection) == nil {
+		return nil, fmt.Errorf("format template must define a %q template", templateNameSection)
+	}
+	if sectionHeading == nil {
+		return nil, fmt.Errorf("section heading pattern must not be nil")
+	}
+
+	return &Format{GroupRules: groupRules, LinksHeading: linksHeading, SectionHeading: sectionHeading, tmpl: tmpl}, nil
+}
+
+// MustNewFormat is like NewFormat but panics if the format cannot be created.
+func MustNewFormat(text string, groupRules []GroupRule, sectionHeading, linksHeading *regexp.Regexp) *Format {
+	f, err := NewFormat(text, groupRules, sectionHeading, linksHeading)
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+// Render renders the section of the given release.
+func (f *Format) Render(r *Release) (*Section, error) {
+	data := &sectionData{Release: r, Groups: r.Group(f.GroupRules)}
+
+	var content bytes.Buffer
+	if execErr := f.tmpl.ExecuteTemplate(&content, templateNameSection, data); execErr != nil {
+		return nil, fmt.Errorf("failed to render section of release %s: %w", r.Version, execErr)
+	}
+	section := &Section{Content: content.String()}
+
+	if f.tmpl.Lookup(templateNameLinks) != nil {
+		var links bytes.Buffer
+		if execErr := f.tmpl.ExecuteTemplate(&links, templateNameLinks, data); execErr != nil {
+			return nil, fmt.Errorf("failed to render links of release %s: %w", r.Version, execErr)
+		}
+		section.Links = links.String()
+	}
+
+	return section, nil
+}
+
+// Insert inserts the given section into the given changelog before the first section heading without changing any
+// other content, so that hand-edited parts are preserved.
+// Link reference definitions of the section that are not already included in the changelog are inserted before the
+// first line matching the links heading, or appended to the end of the changelog.
+// An error wrapping ErrSectionExists is returned when the changelog already contains a section with the same heading,
+// compared by the first two words so that headings with different dates for the same version are also detected.
+func (f *Format) Insert(changelog []byte, section *Section) ([]byte, error) {
+	lines := splitLines(string(changelog))
+	sectionLines := splitLines(section.Content)
+
+	if heading := indexOfMatch(sectionLines, f.SectionHeading); heading >= 0 {
+		key := headingKey(sectionLines[heading])
+		for _, l := range lines {
+			if f.SectionHeading.MatchString(l) && headingKey(l) == key {
+				return nil, fmt.Errorf("%w: %q", ErrSectionExists, l)
+			}
+		}
+	}
+
+	sectionIdx := indexOfMatch(lines, f.SectionHeading)
+	if sectionIdx < 0 {
+		sectionIdx = len(lines)
+		if sectionIdx > 0 && lines[sectionIdx-1] != "" {
+			sectionLines = append([]string{""}, sectionLines...)
+		}
+	}
+	if len(sectionLines) > 0 && sectionLines[len(sectionLines)-1] != "" && sectionIdx < len(lines) {
+		sectionLines = append(sectionLines, "")
+	}
+	lines = insertLines(lines, sectionIdx, sectionLines)
+
+	var linkLines []string
+	for _, ll := range splitLines(section.Links) {
+		exists := false
+		for _, l := range lines {
+			if ll != "" && l == ll {
+				exists = true
+				break
+			}
+		}
+		if !exists && ll != "" {
+			linkLines = append(linkLines, ll)
+		}
+	}
+	if len(linkLines) > 0 {
+		linksIdx := -1
+		if f.LinksHeading != nil {
+			linksIdx = indexOfMatch(lines[sectionIdx+len(sectionLines):], f.LinksHeading)
+			if linksIdx >= 0 {
+				linksIdx += sectionIdx + len(sectionLines)
+			}
+		}
+		if linksIdx < 0 {
+			linksIdx = len(lines)
+			if linksIdx > 0 && lines[linksIdx-1] != "" {
+				linkLines = append([]string{""}, linkLines...)
+			}
+		}
+		lines = insertLines(lines, linksIdx, linkLines)
+	}
+
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// PrependFile renders the section of the given release and inserts it into the changelog file at the given path.
+// The file is created if it does not exist.
+func (f *Format) PrependFile(path string, r *Release) error {
+	section, renderErr := f.Render(r)
+	if renderErr != nil {
+		return renderErr
+	}
+
+	perm := os.FileMode(0o644)
+	changelog, readErr := os.ReadFile(path)
+	switch {
+	case readErr == nil:
+		if info, statErr := os.Stat(path); statErr == nil {
+			perm = info.Mode().Perm()
+		}
+	case !os.IsNotExist(readErr):
+		return fmt.Errorf("failed to read changelog file %q: %w", path, readErr)
+	}
+
+	updated, insertErr := f.Insert(changelog, section)
+	if insertErr != nil {
+		return insertErr
+	}
+	if writeErr := os.WriteFile(path, updated, perm); writeErr != nil {
+		return fmt.Errorf("failed to write changelog file %q: %w", path, writeErr)
+	}
+
+	return nil
+}
+
+// splitLines splits the given text into lines without a trailing empty line.
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(text, "\n")
+}
+
+// headingKey returns the first two words of the given heading line, usually the heading marker and the version.
+func headingKey(heading string) string {
+	fields := strings.Fields(heading)
+	if len(fields) > 2 {
+		fields = fields[:2]
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// indexOfMatch returns the index of the first line matching the given pattern, or -1 if no line matches.
+func indexOfMatch(lines []string, pattern *regexp.Regexp) int {
+	for i, l := range lines {
+		if pattern.MatchString(l) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// insertLines inserts the given lines at the given index.
+func insertLines(lines []string, idx int, inserted []string) []string {
+	result := make([]string, 0, len(lines)+len(inserted))
+	result = append(result, lines[:idx]...)
+	result = append(result, inserted...)
+
+	return append(result, lines[idx:]...)
+}
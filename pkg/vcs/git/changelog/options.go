@@ -0,0 +1,72 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package changelog
+
+import "time"
+
+// DefaultTagPrefix is the default prefix of version tags.
+const DefaultTagPrefix = "v"
+
+// Option is a changelog generation option.
+type Option func(*Options)
+
+// Options are changelog generation options.
+type Options struct {
+	// CompareURLFormat is the format of the URL to compare the history of a release that is formatted with the "from"
+	// and "to" revisions, e.g. "https://github.com/svengreb/golib/compare/%s...%s".
+	// When empty, no compare URL is generated.
+	CompareURLFormat string
+
+	// Date is the date of the release.
+	// When not set, the committer time of the latest commit of the release is used.
+	Date time.Time
+
+	// TagPrefix is the prefix of the version tag that is used in the compare URL when the release ends with HEAD, which
+	// usually means that the version tag has not been created yet.
+	TagPrefix string
+
+	// Version is the version of the release.
+	// When empty, the version is parsed from the revision the release ends with.
+	Version string
+}
+
+// NewOptions creates new changelog generation options.
+func NewOptions(opts ...Option) *Options {
+	opt := &Options{
+		TagPrefix: DefaultTagPrefix,
+	}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	return opt
+}
+
+// WithCompareURLFormat sets the format of the URL to compare the history of a release.
+func WithCompareURLFormat(format string) Option {
+	return func(o *Options) {
+		o.CompareURLFormat = format
+	}
+}
+
+// WithDate sets the date of the release.
+func WithDate(date time.Time) Option {
+	return func(o *Options) {
+		o.Date = date
+	}
+}
+
+// WithTagPrefix sets the prefix of the version tag that is used in the compare URL.
+func WithTagPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.TagPrefix = prefix
+	}
+}
+
+// WithVersion sets the version of the release.
+func WithVersion(version string) Option {
+	return func(o *Options) {
+		o.Version = version
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+
+	"github.com/svengreb/golib/pkg/vcs/git/conventional"
+)
+
+// ChannelRule maps branches to a prerelease channel.
+type ChannelRule struct {
+	// Branch is a glob pattern that is matched against the short name of the branch HEAD is pointing to using the rules
+	// of the "path.Match" function, e.g. "main" or "release/*".
+	Branch string
+
+	// Channel is the prerelease channel for matching branches, e.g. "alpha", "rc" or "dev".
+	// An empty channel disables prerelease versions for matching branches.
+	Channel string
+}
+
+// prerelease stores the information to derive a prerelease version for a channel.
+type prerelease struct {
+	// channel is the prerelease channel.
+	channel string
+
+	// distance is the amount of commits since the latest version tag, or of all commits if there is none.
+	distance int
+
+	// when is the committer time of the commit HEAD is pointing to.
+	when time.Time
+}
+
+// resolveChannel returns the prerelease channel for the given state of HEAD, or an empty string if no prerelease
+// version should be derived.
+// The channel of the first rule whose pattern matches the branch is used while the default channel is used when HEAD
+// is detached and the Branch option is not set, or no rule matches.
+func resolveChannel(head *headState, opt *Options) string {
+	if head.branch == "" {
+		return opt.Channel
+	}
+	for _, rule := range opt.ChannelRules {
+		if matched, _ := path.Match(rule.Branch, head.branch); matched {
+			return rule.Channel
+		}
+	}
+
+	return opt.Channel
+}
+
+// newPrerelease creates the information to derive a prerelease version for the given channel.
+// When no tag candidate has been found, all commits in the history of the commit with the given hash are counted.
+func newPrerelease(
+	index commitgraph.CommitNodeIndex,
+	commitHash plumbing.Hash,
+	candidate *tagCandidate,
+	channel string,
+	opt *Options,
+	p *progress,
+) (*prerelease, error) {
+	node, nodeErr := index.Get(commitHash)
+	if nodeErr != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", commitHash, nodeErr)
+	}
+	pre := &prerelease{channel: channel, when: node.CommitTime()}
+	if candidate != nil {
+		pre.distance = candidate.distance
+		return pre, nil
+	}
+
+	if opt.TraversalMode == TraversalFirstParent {
+		for {
+			if progressErr := p.commit(node.ID()); progressErr != nil {
+				return nil, progressErr
+			}
+			pre.distance++
+			if node.NumParents() == 0 {
+				return pre, nil
+			}
+			parent, parentErr := node.ParentNode(0)
+			if parentErr != nil {
+				return nil, fmt.Errorf("failed to get first parent of commit %s: %w", node.ID(), parentErr)
+			}
+			node = parent
+		}
+	}
+
+	commitIterator := commitgraph.NewCommitNodeIterCTime(node, nil, nil)
+	defer commitIterator.Close()
+	countErr := commitIterator.ForEach(func(node commitgraph.CommitNode) error {
+		if progressErr := p.commit(node.ID()); progressErr != nil {
+			return progressErr
+		}
+		pre.distance++
+		return nil
+	})
+	if countErr != nil {
+		if isCanceled(countErr) {
+			return nil, countErr
+		}
+		return nil, fmt.Errorf("failed to iterate over commits: %w", countErr)
+	}
+
+	return pre, nil
+}
+
+// version returns the prerelease version of the next version after the given base version.
+// The next version is computed by the version scheme as patch version bump at the committer time of HEAD so that the
+// derived version is reproducible, and the prerelease version consists of the channel and the distance, e.g. "rc.3".
+// When the base version is a prerelease version itself, like "1.1.0-rc.1", its release version is used as next version
+// as long as the prerelease version for the channel is ordered after the base version, e.g. "1.1.0-rc.2", otherwise the
+// next version is computed from the release version, e.g. "1.1.1-dev.2", so that prerelease versions never go
+// backwards.
+func (pre *prerelease) version(base *semver.Version, scheme Scheme) (*semver.Version, error) {
+	if base != nil && base.Prerelease() != "" {
+		release, setErr := base.SetPrerelease("")
+		if setErr == nil {
+			release, setErr = release.SetMetadata("")
+		}
+		if setErr != nil {
+			return nil, newError(ErrInvalidVersion, setErr, "failed to get release version of %s", base)
+		}
+		prereleaseVersion, preErr := pre.apply(&release)
+		if preErr != nil || prereleaseVersion.GreaterThan(base) {
+			return prereleaseVersion, preErr
+		}
+		base = &release
+	}
+
+	next, nextErr := scheme.Next(base, conventional.BumpPatch, pre.when)
+	if nextErr != nil {
+		return nil, nextErr
+	}
+
+	return pre.apply(next)
+}
+
+// apply returns the given version with the prerelease version of the channel and distance.
+func (pre *prerelease) apply(version *semver.Version) (*semver.Version, error) {
+	prereleaseVersion, setErr := version.SetPrerelease(fmt.Sprintf("%s.%d", pre.channel, pre.distance))
+	if setErr != nil {
+		return nil, newError(ErrInvalidOption, setErr, "invalid prerelease channel %q", pre.channel)
+	}
+
+	return &prereleaseVersion, nil
+}
+
+// validateChannels validates the prerelease channels and branch patterns of the given options.
+func validateChannels(opt *Options) error {
+	channels := []string{opt.Channel}
+	for _, rule := range opt.ChannelRules {
+		if _, matchErr := path.Match(rule.Branch, ""); matchErr != nil {
+			return newError(ErrInvalidOption, matchErr, "invalid branch pattern %q of channel rule", rule.Branch)
+		}
+		channels = append(channels, rule.Channel)
+	}
+	for _, channel := range channels {
+		if channel == "" {
+			continue
+		}
+		if _, semVerErr := semver.NewVersion("0.0.0-" + channel + ".0"); semVerErr != nil {
+			return newError(ErrInvalidOption, semVerErr, "invalid prerelease channel %q", channel)
+		}
+	}
+
+	return nil
+}
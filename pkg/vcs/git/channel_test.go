@@ -0,0 +1,159 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+func TestDeriveVersion_Channel(t *testing.T) {
+	tr := newTestRepository(t)
+	tagged := tr.commit("first")
+	tr.tag("v1.2.3", tagged, "v1.2.3")
+	tr.commit("second")
+	head := tr.commit("third")
+	hash := head.String()[:8]
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithChannel("dev"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.4-dev.2+"+hash, version.String())
+	assert.Equal(t, "dev", version.Channel)
+	assert.Equal(t, 2, version.CommitsAhead)
+	assert.Equal(t, "v1.2.3", version.LatestVersionTag.Name().Short())
+
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path,
+		glGit.WithChannel("dev"),
+		glGit.WithHashAbbrevLength(0),
+		glGit.WithDirtyMark(glGit.DefaultDirtyMark),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.4-dev.2", version.String())
+
+	// Later snapshot builds must be ordered after earlier ones while the next release is ordered after all of them.
+	earlier := semver.MustParse("1.2.4-dev.1")
+	assert.True(t, version.GreaterThan(earlier))
+	assert.True(t, semver.MustParse("1.2.4").GreaterThan(version.Version))
+
+	// Prerelease versions are only derived when HEAD is not pointing exactly to the tag.
+	if err = tr.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, tagged)); err != nil {
+		assert.FailNow(t, "failed to detach HEAD", "error: %v", err)
+	}
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithChannel("dev"), glGit.WithLong(true))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3+0."+tagged.String()[:8], version.String())
+	assert.Empty(t, version.Channel)
+}
+
+func TestDeriveVersion_ChannelRules(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.tag("v1.0.0", tr.commit("first"), "v1.0.0")
+	head := tr.commit("second")
+	for _, branch := range []string{"release/1.0", "feature/a"} {
+		if err := tr.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), head)); err != nil {
+			assert.FailNow(t, "failed to create branch", "branch: %q\nerror: %v", branch, err)
+		}
+	}
+	rules := glGit.WithChannelRules(
+		glGit.ChannelRule{Branch: "master", Channel: "alpha"},
+		glGit.ChannelRule{Branch: "release/*", Channel: "rc"},
+		glGit.ChannelRule{Branch: "stable"},
+	)
+
+	testCases := []struct {
+		branch   string
+		expected string
+	}{
+		{branch: "master", expected: "1.0.1-alpha.1"},
+		{branch: "release/1.0", expected: "1.0.1-rc.1"},
+		{branch: "feature/a", expected: "1.0.1-dev.1"},
+		// Detached HEAD uses the default channel.
+		{expected: "1.0.1-dev.1"},
+	}
+
+	for _, tc := range testCases {
+		target := plumbing.NewHashReference(plumbing.HEAD, head)
+		if tc.branch != "" {
+			target = plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(tc.branch))
+		}
+		if err := tr.repo.Storer.SetReference(target); err != nil {
+			assert.FailNow(t, "failed to check out branch", "branch: %q\nerror: %v", tc.branch, err)
+		}
+
+		version, err := glGit.DeriveVersion(testDefaultVersion, tr.path,
+			rules,
+			glGit.WithChannel("dev"),
+			glGit.WithHashAbbrevLength(0),
+		)
+		if assert.NoError(t, err, "branch: %q", tc.branch) {
+			assert.Equal(t, tc.expected, version.String(), "branch: %q", tc.branch)
+		}
+	}
+
+	// A rule without a channel disables prerelease versions.
+	if err := tr.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("stable"), head)); err != nil {
+		assert.FailNow(t, "failed to create branch", "error: %v", err)
+	}
+	if err := tr.repo.Storer.SetReference(
+		plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("stable")),
+	); err != nil {
+		assert.FailNow(t, "failed to check out branch", "error: %v", err)
+	}
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, rules, glGit.WithChannel("dev"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.0+1."+head.String()[:8], version.String())
+}
+
+func TestDeriveVersion_ChannelWithoutTag(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.commit("first")
+	tr.commit("second")
+	tr.commit("third")
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithChannel("nightly"), glGit.WithHashAbbrevLength(0))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0.1-nightly.3", version.String())
+
+	_, err = glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithChannel("in valid"))
+	assert.True(t, errors.Is(err, glGit.ErrInvalidOption))
+	_, err = glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithChannelRules(glGit.ChannelRule{Branch: "["}))
+	assert.True(t, errors.Is(err, glGit.ErrInvalidOption))
+}
+
+func TestDeriveVersion_ChannelAfterPrereleaseTag(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.tag("v1.1.0-rc.1", tr.commit("first"), "v1.1.0-rc.1")
+	tr.commit("second")
+	tr.commit("third")
+	tag := semver.MustParse("1.1.0-rc.1")
+
+	testCases := []struct {
+		channel  string
+		expected string
+	}{
+		// Channels ordered after the prerelease version of the tag keep the release version of the tag...
+		{channel: "rc", expected: "1.1.0-rc.2"},
+		{channel: "rc2", expected: "1.1.0-rc2.2"},
+		// ...while all other channels are derived from the next version to never be ordered before the tag.
+		{channel: "dev", expected: "1.1.1-dev.2"},
+		{channel: "alpha", expected: "1.1.1-alpha.2"},
+	}
+
+	for _, tc := range testCases {
+		version, err := glGit.DeriveVersion(testDefaultVersion, tr.path,
+			glGit.WithChannel(tc.channel),
+			glGit.WithHashAbbrevLength(0),
+		)
+		if assert.NoError(t, err, "channel: %q", tc.channel) {
+			assert.Equal(t, tc.expected, version.String(), "channel: %q", tc.channel)
+			assert.True(t, version.GreaterThan(tag), "channel: %q", tc.channel)
+		}
+	}
+}
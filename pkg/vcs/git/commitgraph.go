@@ -0,0 +1,41 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"path"
+
+	"github.com/go-git/go-git/v5"
+	commitgraphFormat "github.com/go-git/go-git/v5/plumbing/format/commitgraph"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// commitGraphFilePath is the path of the commit-graph file relative to the Git directory.
+var commitGraphFilePath = path.Join("objects", "info", "commit-graph")
+
+// openCommitNodeIndex opens an index of commit nodes for the given repository.
+// When the repository has a commit-graph file, written by the Git "commit-graph write" or "gc" commands, it is used to
+// look up the parents and committer time of commits without decoding the commit objects. Commits that are not included
+// in the file, like commits created after it has been written, as well as repositories without such a file fall back to
+// the object storage. Note that split commit-graph chains are not supported and also fall back to the object storage.
+// The returned function must be called to close the commit-graph file when the index is no longer used.
+func openCommitNodeIndex(repo *git.Repository) (commitgraph.CommitNodeIndex, func()) {
+	fsStorage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return commitgraph.NewObjectCommitNodeIndex(repo.Storer), func() {}
+	}
+
+	file, openErr := fsStorage.Filesystem().Open(commitGraphFilePath)
+	if openErr != nil {
+		return commitgraph.NewObjectCommitNodeIndex(repo.Storer), func() {}
+	}
+	index, indexErr := commitgraphFormat.OpenFileIndex(file)
+	if indexErr != nil {
+		_ = file.Close()
+		return commitgraph.NewObjectCommitNodeIndex(repo.Storer), func() {}
+	}
+
+	return commitgraph.NewGraphCommitNodeIndex(index, repo.Storer), func() { _ = file.Close() }
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+// Package conventional provides a parser for commit messages that follow the "Conventional Commits" specification and
+// functions to derive the type of version bump they are implying.
+// See https://www.conventionalcommits.org for more details about "Conventional Commits".
+package conventional
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// FooterTokenBreakingChange is the footer token that indicates a breaking change.
+	FooterTokenBreakingChange = "BREAKING CHANGE"
+
+	// FooterTokenBreakingChangeAlt is the alternative footer token that indicates a breaking change.
+	FooterTokenBreakingChangeAlt = "BREAKING-CHANGE"
+
+	// TypeFeat is the commit type of new features.
+	TypeFeat = "feat"
+
+	// TypeFix is the commit type of bug fixes.
+	TypeFix = "fix"
+
+	// TypePerf is the commit type of performance improvements.
+	TypePerf = "perf"
+)
+
+// Bump is the type of version bump implied by commits.
+// The values are ordered by their significance so that a higher value always takes precedence over a lower one.
+type Bump int
+
+const (
+	// BumpNone indicates that no version bump is implied.
+	BumpNone Bump = iota
+
+	// BumpPatch indicates a bump of the patch version.
+	BumpPatch
+
+	// BumpMinor indicates a bump of the minor version.
+	BumpMinor
+
+	// BumpMajor indicates a bump of the major version.
+	BumpMajor
+)
+
+// ErrInvalidHeader is the error returned when the header of a commit message does not follow the "Conventional
+// Commits" specification.
+var ErrInvalidHeader = errors.New("commit message header does not follow the Conventional Commits specification")
+
+var (
+	// headerRegExp matches the header of a commit message in the format "type(scope)!: description" where the scope and
+	// the exclamation mark are optional.
+	headerRegExp = regexp.MustCompile(`^([a-zA-Z][\w-]*)(?:\(([^()\r\n]*)\))?(!)?: (\S.*)$`)
+
+	// footerRegExp matches the first line of a footer in the format "Token: value" or "Token #value".
+	footerRegExp = regexp.MustCompile(`^(BREAKING[ -]CHANGE|[\w-]+)(?:: | #)(.*)$`)
+
+	// DefaultTypeBumps maps the commit types to the version bump they are implying by default.
+	// All other types do not imply a version bump unless the commit is marked as breaking change.
+	DefaultTypeBumps = map[string]Bump{
+		TypeFeat: BumpMinor,
+		TypeFix:  BumpPatch,
+		TypePerf: BumpPatch,
+	}
+)
+
+// Commit is a parsed commit message that follows the "Conventional Commits" specification.
+type Commit struct {
+	// Body is the optional free-form body of the commit message.
+	Body string
+
+	// Breaking indicates whether the commit introduces a breaking change, either through an exclamation mark in the
+	// header or through a "BREAKING CHANGE" footer.
+	Breaking bool
+
+	// Description is the short summary of the change in the header.
+	Description string
+
+	// Footers are the optional footers of the commit message.
+	Footers []Footer
+
+	// Scope is the optional scope of the change in the header.
+	Scope string
+
+	// Type is the type of the change in the header, converted to lowercase.
+	Type string
+}
+
+// Footer is a footer of a commit message.
+type Footer struct {
+	// Token is the token of the footer, e.g. "BREAKING CHANGE" or "Reviewed-by".
+	Token string
+
+	// Value is the value of the footer that can span multiple lines.
+	Value string
+}
+
+// String returns a string representation of the version bump.
+func (b Bump) String() string {
+	switch b {
+	case BumpNone:
+		return "none"
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(b))
+	}
+}
+
+// Parse parses the given commit message.
+// An error wrapping ErrInvalidHeader is returned when the header does not follow the "Conventional Commits"
+// specification.
+func Parse(message string) (*Commit, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	header := strings.TrimSpace(lines[0])
+	matches := headerRegExp.FindStringSubmatch(header)
+	if matches == nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidHeader, header)
+	}
+
+	c := &Commit{
+		Type:        strings.ToLower(matches[1]),
+		Scope:       strings.TrimSpace(matches[2]),
+		Breaking:    matches[3] != "",
+		Description: strings.TrimSpace(matches[4]),
+	}
+
+	// Footers start at the first line after a blank line that matches the footer format while all following lines that
+	// do not start a new footer belong to the value of the previous footer.
+	bodyEnd := len(lines)
+	for i := 2; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i-1]) == "" && footerRegExp.MatchString(lines[i]) {
+			bodyEnd = i
+			break
+		}
+	}
+	if len(lines) > 1 {
+		c.Body = strings.TrimSpace(strings.Join(lines[1:bodyEnd], "\n"))
+	}
+	for _, line := range lines[bodyEnd:] {
+		if footer := footerRegExp.FindStringSubmatch(line); footer != nil {
+			c.Footers = append(c.Footers, Footer{Token: footer[1], Value: footer[2]})
+			continue
+		}
+		last := &c.Footers[len(c.Footers)-1]
+		last.Value = fmt.Sprintf("%s\n%s", last.Value, line)
+	}
+	for i := range c.Footers {
+		c.Footers[i].Value = strings.TrimSpace(c.Footers[i].Value)
+		if IsBreakingChangeToken(c.Footers[i].Token) {
+			c.Breaking = true
+		}
+	}
+
+	return c, nil
+}
+
+// IsBreakingChangeToken checks if the given footer token indicates a breaking change.
+func IsBreakingChangeToken(token string) bool {
+	return token == FooterTokenBreakingChange || token == FooterTokenBreakingChangeAlt
+}
+
+// BreakingChange returns the description of the breaking change from the "BREAKING CHANGE" footer, or the description
+// of the header when the commit is only marked as breaking change through an exclamation mark.
+// An empty string is returned when the commit does not introduce a breaking change.
+func (c *Commit) BreakingChange() string {
+	if !c.Breaking {
+		return ""
+	}
+	for _, footer := range c.Footers {
+		if IsBreakingChangeToken(footer.Token) {
+			return footer.Value
+		}
+	}
+
+	return c.Description
+}
+
+// Bump returns the version bump implied by the commit using the given mapping of commit types to version bumps.
+// The DefaultTypeBumps are used when the given mapping is nil.
+// Breaking changes always imply a major version bump.
+func (c *Commit) Bump(typeBumps map[string]Bump) Bump {
+	if c.Breaking {
+		return BumpMajor
+	}
+	if typeBumps == nil {
+		typeBumps = DefaultTypeBumps
+	}
+
+	return typeBumps[c.Type]
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package conventional_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/svengreb/golib/pkg/vcs/git/conventional"
+)
+
+func TestParse(t *testing.T) {
+	msg := "feat(vcs/git)!: derive versions from HEAD\n\n" +
+		"The version is now derived from HEAD itself.\n\nSecond paragraph.\n\n" +
+		"BREAKING CHANGE: the current branch is not searched anymore\n  and HEAD is used instead.\n" +
+		"Closes #42\nReviewed-by: Sven Greb\n"
+
+	c, err := conventional.Parse(msg)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "feat", c.Type)
+	assert.Equal(t, "vcs/git", c.Scope)
+	assert.Equal(t, "derive versions from HEAD", c.Description)
+	assert.Equal(t, "The version is now derived from HEAD itself.\n\nSecond paragraph.", c.Body)
+	assert.True(t, c.Breaking)
+	assert.Equal(t, []conventional.Footer{
+		{Token: conventional.FooterTokenBreakingChange, Value: "the current branch is not searched anymore\n  and HEAD is used instead."},
+		{Token: "Closes", Value: "42"},
+		{Token: "Reviewed-by", Value: "Sven Greb"},
+	}, c.Footers)
+	assert.Equal(t, "the current branch is not searched anymore\n  and HEAD is used instead.", c.BreakingChange())
+}
+
+func TestParse_FailWithInvalidHeader(t *testing.T) {
+	for _, msg := range []string{"", "Merge branch 'main'", "feat:missing space", "feat(scope: unclosed", "feat: "} {
+		c, err := conventional.Parse(msg)
+
+		assert.Nil(t, c, msg)
+		assert.True(t, errors.Is(err, conventional.ErrInvalidHeader), msg)
+	}
+}
+
+func TestCommit_Bump(t *testing.T) {
+	testCases := []struct {
+		msg      string
+		expected conventional.Bump
+	}{
+		{"feat: add feature", conventional.BumpMinor},
+		{"Fix(io/fs): fix bug", conventional.BumpPatch},
+		{"perf: improve performance", conventional.BumpPatch},
+		{"docs: update documentation", conventional.BumpNone},
+		{"chore!: drop support for Go 1.16", conventional.BumpMajor},
+		{"refactor: rename API\n\nBREAKING-CHANGE: renamed", conventional.BumpMajor},
+	}
+
+	for _, tc := range testCases {
+		c, err := conventional.Parse(tc.msg)
+
+		assert.NoError(t, err, tc.msg)
+		assert.Equal(t, tc.expected, c.Bump(nil), tc.msg)
+	}
+
+	c, err := conventional.Parse("docs: update documentation")
+	assert.NoError(t, err)
+	assert.Equal(t, conventional.BumpPatch, c.Bump(map[string]conventional.Bump{"docs": conventional.BumpPatch}))
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// tagCandidate is a version tag that is a suitable candidate to describe a commit.
+type tagCandidate struct {
+	// annotated indicates whether the tag is an annotated tag.
+	annotated bool
+
+	// commitHash is the hash of the commit the tag is pointing to.
+	commitHash plumbing.Hash
+
+	// distance is the amount of commits between the commit the tag is pointing to and the described commit.
+	distance int
+
+	// ref is the reference of the tag.
+	ref *plumbing.Reference
+
+	// signer is the OpenPGP entity whose key signed the tag when tag signatures are verified.
+	signer *openpgp.Entity
+
+	// taggerWhen is the date of an annotated tag.
+	taggerWhen time.Time
+
+	// version is the semantic version parsed from the tag name.
+	version *semver.Version
+}
+
+// replaces checks if the tag candidate should replace the given candidate that is pointing to the same commit.
+// Like Git, annotated tags are preferred over lightweight tags and newer annotated tags are preferred over older ones.
+func (tc *tagCandidate) replaces(other *tagCandidate) bool {
+	if tc.annotated != other.annotated {
+		return tc.annotated
+	}
+
+	return tc.annotated && tc.taggerWhen.After(other.taggerWhen)
+}
+
+// collectTagCandidates collects all suitable version tag candidates mapped to the hash of the commit they are pointing
+// to.
+// Only tags with the given prefix are considered and the prefix is stripped before the remaining name is parsed as
+// version. When the given constraint is not nil, only tags whose version satisfies it are considered.
+func collectTagCandidates(
+	repo *git.Repository,
+	prefix string,
+	constraint *semver.Constraints,
+	opt *Options,
+	p *progress,
+) (map[plumbing.Hash]*tagCandidate, error) {
+	tagIterator, repoTagsErr := repo.Tags()
+	if repoTagsErr != nil {
+		return nil, fmt.Errorf("failed to get all tag references: %w", repoTagsErr)
+	}
+	defer tagIterator.Close()
+
+	var refs []*plumbing.Reference
+	if tagIterErr := tagIterator.ForEach(func(tag *plumbing.Reference) error {
+		refs = append(refs, tag)
+		return nil
+	}); tagIterErr != nil {
+		return nil, fmt.Errorf("failed to iterate over tags: %w", tagIterErr)
+	}
+	// Sort the tags by name to ensure a stable selection between multiple lightweight tags pointing to the same commit.
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Name() < refs[j].Name() })
+
+	tags := make(map[plumbing.Hash]*tagCandidate)
+	for _, ref := range refs {
+		if progressErr := p.tag(); progressErr != nil {
+			return nil, progressErr
+		}
+		name := ref.Name().Short()
+		if !matchesTagName(name, opt) {
+			continue
+		}
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		// Only include tags that have a valid version format of the version scheme.
+		semVersion, semVerParseErr := opt.versionScheme().Parse(strings.TrimPrefix(name, prefix))
+		if semVerParseErr != nil {
+			continue
+		}
+		if constraint != nil && !constraint.Check(semVersion) {
+			continue
+		}
+
+		candidate := &tagCandidate{ref: ref, version: semVersion, commitHash: ref.Hash()}
+		tagObject, tagObjectErr := repo.TagObject(ref.Hash())
+		switch {
+		case tagObjectErr == nil:
+			commit, commitErr := tagObject.Commit()
+			if commitErr != nil {
+				// Ignore tags that are not pointing to a commit.
+				if errors.Is(commitErr, object.ErrUnsupportedObject) {
+					continue
+				}
+				return nil, fmt.Errorf("failed to get commit of tag %s: %w", name, commitErr)
+			}
+			candidate.annotated = true
+			candidate.commitHash = commit.Hash
+			candidate.taggerWhen = tagObject.Tagger.When
+			if opt.VerifyKeyRing != "" {
+				// Ignore unsigned tags and tags whose signature cannot be verified by any of the trusted keys.
+				if tagObject.PGPSignature == "" {
+					continue
+				}
+				signer, verifyErr := tagObject.Verify(opt.VerifyKeyRing)
+				if verifyErr != nil {
+					continue
+				}
+				candidate.signer = signer
+			}
+		case errors.Is(tagObjectErr, plumbing.ErrObjectNotFound):
+			if !opt.Tags || opt.VerifyKeyRing != "" {
+				continue
+			}
+		default:
+			return nil, fmt.Errorf("failed to get tag object of tag %s: %w", name, tagObjectErr)
+		}
+
+		if existing, ok := tags[candidate.commitHash]; !ok || candidate.replaces(existing) {
+			tags[candidate.commitHash] = candidate
+		}
+	}
+
+	return tags, nil
+}
+
+// matchesTagName checks if the given tag name matches at least one of the match patterns, if any, and none of the
+// exclude patterns.
+// The patterns must have been validated before.
+func matchesTagName(name string, opt *Options) bool {
+	for _, pattern := range opt.ExcludePatterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return false
+		}
+	}
+	if len(opt.MatchPatterns) == 0 {
+		return true
+	}
+	for _, pattern := range opt.MatchPatterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describe searches for the tag candidate that describes the commit with the given hash best.
+// The candidate with the lowest distance in the history of the described commit is returned, or nil if no suitable
+// candidate has been found.
+func describe(
+	index commitgraph.CommitNodeIndex,
+	commitHash plumbing.Hash,
+	tags map[plumbing.Hash]*tagCandidate,
+	opt *Options,
+	p *progress,
+) (*tagCandidate, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	// Prefer tags that are pointing exactly to the described commit.
+	if candidate, ok := tags[commitHash]; ok {
+		return candidate, nil
+	}
+	if opt.Candidates == 0 {
+		return nil, nil
+	}
+	if opt.TraversalMode == TraversalFirstParent || opt.TraversalMode == TraversalGraph {
+		return describeGraph(index, commitHash, tags, opt, p)
+	}
+
+	head, headErr := index.Get(commitHash)
+	if headErr != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", commitHash, headErr)
+	}
+	commitIterator := commitgraph.NewCommitNodeIterCTime(head, nil, nil)
+	defer commitIterator.Close()
+
+	// Search for the first tag candidate in the history of the described commit ordered by committer time.
+	// The distance is the position in this order, so the first found candidate is always the nearest one and the
+	// traversal can stop without searching for further candidates.
+	var nearest *tagCandidate
+	distance := -1
+	tagCommitIterErr := commitIterator.ForEach(func(node commitgraph.CommitNode) error {
+		if progressErr := p.commit(node.ID()); progressErr != nil {
+			return progressErr
+		}
+		distance++
+		if candidate, ok := tags[node.ID()]; ok {
+			candidate.distance = distance
+			nearest = candidate
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if tagCommitIterErr != nil {
+		if isCanceled(tagCommitIterErr) {
+			return nil, tagCommitIterErr
+		}
+		return nil, fmt.Errorf("failed to iterate over commits: %w", tagCommitIterErr)
+	}
+
+	return nearest, nil
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	commitgraphFormat "github.com/go-git/go-git/v5/plumbing/format/commitgraph"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+const (
+	// largeTestRepositoryCommits is the amount of mainline commits of the generated large test repository.
+	largeTestRepositoryCommits = 5000
+
+	// largeTestRepositoryTagInterval is the amount of mainline commits between version tags of the generated large test
+	// repository.
+	largeTestRepositoryTagInterval = 400
+)
+
+// largeTestRepository is a generated Git repository with a large history for testing and benchmark purposes.
+type largeTestRepository struct {
+	commitData map[plumbing.Hash]*commitgraphFormat.CommitData
+	path       string
+	repo       *git.Repository
+	tb         testing.TB
+	tree       plumbing.Hash
+}
+
+// newLargeTestRepository generates a repository in a temporary directory with the given amount of mainline commits.
+// Every tenth mainline commit merges a side branch of two commits and every mainline commit at the given tag interval
+// is tagged with an annotated version tag, while the commits in between are tagged with lightweight tags that are no
+// version tags.
+// The commit objects are written directly to the object storage instead of committing through a worktree to keep the
+// generation fast.
+func newLargeTestRepository(tb testing.TB, commits, tagInterval int) *largeTestRepository {
+	tb.Helper()
+	dir := tb.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		assert.FailNow(tb, "failed to initialize repository", "path: %q\nerror: %v", dir, err)
+	}
+	ltr := &largeTestRepository{
+		commitData: make(map[plumbing.Hash]*commitgraphFormat.CommitData),
+		path:       dir,
+		repo:       repo,
+		tb:         tb,
+	}
+
+	obj := repo.Storer.NewEncodedObject()
+	if err = (&object.Tree{}).Encode(obj); err != nil {
+		assert.FailNow(tb, "failed to encode tree", "error: %v", err)
+	}
+	if ltr.tree, err = repo.Storer.SetEncodedObject(obj); err != nil {
+		assert.FailNow(tb, "failed to store tree", "error: %v", err)
+	}
+
+	var head plumbing.Hash
+	for i := 1; i <= commits; i++ {
+		var parents []plumbing.Hash
+		if !head.IsZero() {
+			parents = append(parents, head)
+		}
+		if i%10 == 0 && !head.IsZero() {
+			side := ltr.commit(fmt.Sprintf("side commit %d", 2*i), head)
+			side = ltr.commit(fmt.Sprintf("side commit %d", 2*i+1), side)
+			parents = append(parents, side)
+		}
+		head = ltr.commit(fmt.Sprintf("commit %d", i), parents...)
+
+		var opts *git.CreateTagOptions
+		name := fmt.Sprintf("build-%d", i)
+		if i%tagInterval == 0 {
+			name = fmt.Sprintf("v%d.0.0", i/tagInterval)
+			opts = &git.CreateTagOptions{Tagger: ltr.signature(len(ltr.commitData)), Message: name}
+		}
+		if _, err = repo.CreateTag(name, head, opts); err != nil {
+			assert.FailNow(tb, "failed to create tag", "tag: %q\nerror: %v", name, err)
+		}
+	}
+
+	ref := plumbing.NewHashReference(plumbing.Master, head)
+	if err = repo.Storer.SetReference(ref); err != nil {
+		assert.FailNow(tb, "failed to set reference", "reference: %q\nerror: %v", ref.Name(), err)
+	}
+
+	return ltr
+}
+
+// signature returns a signature with a time that is incremented for each commit to ensure a stable commit order.
+func (ltr *largeTestRepository) signature(commit int) *object.Signature {
+	return &object.Signature{
+		Name:  "Sven Greb",
+		Email: "development@svengreb.de",
+		When:  time.Date(2020, 11, 21, 12, 0, 0, 0, time.UTC).Add(time.Duration(commit) * time.Minute),
+	}
+}
+
+// commit writes a commit object with the given message and parents.
+func (ltr *largeTestRepository) commit(msg string, parents ...plumbing.Hash) plumbing.Hash {
+	ltr.tb.Helper()
+	sig := ltr.signature(len(ltr.commitData))
+	c := &object.Commit{Author: *sig, Committer: *sig, Message: msg, ParentHashes: parents, TreeHash: ltr.tree}
+	obj := ltr.repo.Storer.NewEncodedObject()
+	if err := c.Encode(obj); err != nil {
+		assert.FailNow(ltr.tb, "failed to encode commit", "error: %v", err)
+	}
+	hash, err := ltr.repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		assert.FailNow(ltr.tb, "failed to store commit", "error: %v", err)
+	}
+
+	generation := 1
+	for _, parent := range parents {
+		if gen := ltr.commitData[parent].Generation + 1; gen > generation {
+			generation = gen
+		}
+	}
+	ltr.commitData[hash] = &commitgraphFormat.CommitData{
+		Generation:   generation,
+		ParentHashes: parents,
+		TreeHash:     ltr.tree,
+		When:         sig.When,
+	}
+
+	return hash
+}
+
+// writeCommitGraph writes a commit-graph file that includes all commits like the Git "commit-graph write" command.
+func (ltr *largeTestRepository) writeCommitGraph() {
+	ltr.tb.Helper()
+	index := commitgraphFormat.NewMemoryIndex()
+	for hash, data := range ltr.commitData {
+		index.Add(hash, data)
+	}
+
+	file, err := os.Create(filepath.Join(ltr.path, git.GitDirName, "objects", "info", "commit-graph"))
+	if err != nil {
+		assert.FailNow(ltr.tb, "failed to create commit-graph file", "error: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	if err = commitgraphFormat.NewEncoder(file).Encode(index); err != nil {
+		assert.FailNow(ltr.tb, "failed to encode commit-graph file", "error: %v", err)
+	}
+}
+
+func TestDeriveVersion_CommitGraph(t *testing.T) {
+	ltr := newLargeTestRepository(t, 500, 100)
+	modes := []glGit.TraversalMode{glGit.TraversalCommitterTime, glGit.TraversalFirstParent, glGit.TraversalGraph}
+
+	expected := make(map[glGit.TraversalMode]string)
+	for _, mode := range modes {
+		version, err := glGit.DeriveVersion(testDefaultVersion, ltr.path, glGit.WithTraversalMode(mode),
+			glGit.WithHashAbbrevLength(0), glGit.WithLong(true))
+		if assert.NoError(t, err, "traversal mode: %d", mode) {
+			assert.Equal(t, "5.0.0", version.String(), "traversal mode: %d", mode)
+			expected[mode] = fmt.Sprintf("%s-%d", version.LatestVersionTag.Name(), version.CommitsAhead)
+		}
+	}
+
+	// Commits that are not included in the commit-graph file must fall back to the object storage.
+	ltr.writeCommitGraph()
+	headRef, err := ltr.repo.Head()
+	if err != nil {
+		assert.FailNow(t, "failed to get HEAD", "error: %v", err)
+	}
+	head := ltr.commit("feat: commit after commit-graph", headRef.Hash())
+	if err = ltr.repo.Storer.SetReference(plumbing.NewHashReference(headRef.Name(), head)); err != nil {
+		assert.FailNow(t, "failed to set reference", "reference: %q\nerror: %v", headRef.Name(), err)
+	}
+
+	for _, mode := range modes {
+		version, deriveErr := glGit.DeriveVersion(testDefaultVersion, ltr.path, glGit.WithTraversalMode(mode))
+		if assert.NoError(t, deriveErr, "traversal mode: %d", mode) {
+			assert.Equal(t, head, version.CommitHash)
+			assert.Equal(t, expected[mode],
+				fmt.Sprintf("%s-%d", version.LatestVersionTag.Name(), version.CommitsAhead-1), "traversal mode: %d", mode)
+		}
+	}
+}
+
+func BenchmarkDeriveVersion(b *testing.B) {
+	for _, commitGraph := range []bool{false, true} {
+		ltr := newLargeTestRepository(b, largeTestRepositoryCommits, largeTestRepositoryTagInterval)
+		if commitGraph {
+			ltr.writeCommitGraph()
+		}
+		for _, mode := range []glGit.TraversalMode{
+			glGit.TraversalCommitterTime,
+			glGit.TraversalFirstParent,
+			glGit.TraversalGraph,
+		} {
+			b.Run(fmt.Sprintf("mode=%d/commit-graph=%t", mode, commitGraph), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := glGit.DeriveVersion(testDefaultVersion, ltr.path, glGit.WithTraversalMode(mode)); err != nil {
+						b.Fatalf("failed to derive version: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// ChangeKind is the kind of change of a file.
+type ChangeKind int
+
+const (
+	// ChangeModified indicates that the content or mode of a file has been modified.
+	ChangeModified ChangeKind = iota
+
+	// ChangeAdded indicates that a file has been added.
+	ChangeAdded
+
+	// ChangeDeleted indicates that a file has been deleted.
+	ChangeDeleted
+
+	// ChangeRenamed indicates that a file has been renamed, and possibly modified.
+	ChangeRenamed
+)
+
+// String returns the single letter representation of the change kind as used by the Git "status" and "diff" commands.
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeModified:
+		return "M"
+	case ChangeAdded:
+		return "A"
+	case ChangeDeleted:
+		return "D"
+	case ChangeRenamed:
+		return "R"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// FileChange is the change of a single file between two states of a repository.
+type FileChange struct {
+	// AddedLines is the amount of added lines, which is always 0 for binary files.
+	AddedLines int
+
+	// Binary indicates whether the file is a binary file in any of both states.
+	Binary bool
+
+	// From is the path of the file, relative to the root directory of the repository, before the change.
+	// It is empty when the file has been added.
+	From string
+
+	// Kind is the kind of the change.
+	Kind ChangeKind
+
+	// RemovedLines is the amount of removed lines, which is always 0 for binary files.
+	RemovedLines int
+
+	// To is the path of the file, relative to the root directory of the repository, after the change.
+	// It is empty when the file has been deleted.
+	To string
+}
+
+// Path returns the path of the file after the change, or before the change when the file has been deleted.
+func (c *FileChange) Path() string {
+	if c.To != "" {
+		return c.To
+	}
+
+	return c.From
+}
+
+// DiffRevisions returns the changes of files between the commits of the given revisions, ordered by path.
+// The revisions can be any revision supported by the Git "rev-parse" command, like a branch, tag or commit hash, and
+// default to HEAD when empty. Renamed files are detected like the Git "diff" command does by default.
+// The DetectDotGit and CeilingDirs options are used to find the repository while all other options are ignored.
+func DiffRevisions(repositoryPath, from, to string, opts ...Option) ([]*FileChange, error) {
+	repo, repoOpenErr := openRepository(repositoryPath, NewOptions(opts...))
+	if repoOpenErr != nil {
+		return nil, repoOpenErr
+	}
+
+	return DiffRevisionsFromRepository(repo, from, to)
+}
+
+// DiffRevisionsFromRepository is like DiffRevisions but compares the commits of the given already opened repository.
+func DiffRevisionsFromRepository(repo *git.Repository, from, to string) ([]*FileChange, error) {
+	if repo == nil {
+		return nil, newError(ErrRepositoryNotFound, nil, "repository must not be nil")
+	}
+
+	var trees [2]*object.Tree
+	for i, revision := range []string{from, to} {
+		hash, resolveErr := resolveRevision(repo, revision)
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		commit, commitErr := repo.CommitObject(hash)
+		if commitErr != nil {
+			return nil, fmt.Errorf("failed to get commit %s: %w", hash, commitErr)
+		}
+		tree, treeErr := commit.Tree()
+		if treeErr != nil {
+			return nil, fmt.Errorf("failed to get tree of commit %s: %w", hash, treeErr)
+		}
+		trees[i] = tree
+	}
+
+	changes, diffErr := object.DiffTreeWithOptions(context.Background(), trees[0], trees[1], object.DefaultDiffTreeOptions)
+	if diffErr != nil {
+		return nil, fmt.Errorf("failed to diff revisions %q and %q: %w", from, to, diffErr)
+	}
+
+	return newFileChanges(changes)
+}
+
+// newFileChanges creates the file changes of the given tree changes.
+// The content of the files is compared through the patch of the changes to count the added and removed lines.
+func newFileChanges(changes object.Changes) ([]*FileChange, error) {
+	patch, patchErr := changes.Patch()
+	if patchErr != nil {
+		return nil, fmt.Errorf("failed to create patch: %w", patchErr)
+	}
+
+	// The file patches are created in the same order as the changes.
+	filePatches := patch.FilePatches()
+	fileChanges := make([]*FileChange, 0, len(changes))
+	for i, change := range changes {
+		action, actionErr := change.Action()
+		if actionErr != nil {
+			return nil, actionErr
+		}
+
+		fc := &FileChange{From: change.From.Name, To: change.To.Name}
+		switch {
+		case action == merkletrie.Insert:
+			fc.Kind = ChangeAdded
+		case action == merkletrie.Delete:
+			fc.Kind = ChangeDeleted
+		case fc.From != fc.To:
+			fc.Kind = ChangeRenamed
+		default:
+			fc.Kind = ChangeModified
+		}
+		fc.Binary = filePatches[i].IsBinary()
+		fc.AddedLines, fc.RemovedLines = countLines(filePatches[i])
+		fileChanges = append(fileChanges, fc)
+	}
+	sort.Slice(fileChanges, func(i, j int) bool { return fileChanges[i].Path() < fileChanges[j].Path() })
+
+	return fileChanges, nil
+}
+
+// countLines counts the added and removed lines of the given file patch.
+// The last line of a chunk is also counted when it does not end with a line break.
+func countLines(fp fdiff.FilePatch) (added, removed int) {
+	for _, chunk := range fp.Chunks() {
+		content := chunk.Content()
+		if content == "" {
+			continue
+		}
+		lines := strings.Count(content, "\n")
+		if !strings.HasSuffix(content, "\n") {
+			lines++
+		}
+		switch chunk.Type() {
+		case fdiff.Add:
+			added += lines
+		case fdiff.Delete:
+			removed += lines
+		}
+	}
+
+	return added, removed
+}
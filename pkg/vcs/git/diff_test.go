@@ -0,0 +1,59 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+func TestDiffRevisions(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.stageFile("README.md", "# golib\n\nA library.\n")
+	tr.stageFile("main.go", "package main\n")
+	tr.stageFile("logo.png", "\x89PNG\x00\x01")
+	tr.stageFile("docs/guide.md", strings.Repeat("guide\n", 10))
+	first := tr.commitStaged("first")
+	tr.tag("v1.0.0", first, "v1.0.0")
+
+	tr.stageFile("README.md", "# golib\n\nA Go library.\nWith a second line.\n")
+	tr.stageFile("logo.png", "\x89PNG\x00\x02")
+	tr.stageFile("LICENSE", "MIT\n")
+	tr.stageFile("docs/manual.md", strings.Repeat("guide\n", 10)+"manual\n")
+	wt := tr.worktree()
+	for _, name := range []string{"main.go", "docs/guide.md"} {
+		if _, err := wt.Remove(name); err != nil {
+			assert.FailNow(t, "failed to remove file", "file: %q\nerror: %v", name, err)
+		}
+	}
+	tr.commitStaged("second")
+
+	changes, err := glGit.DiffRevisions(tr.path, "v1.0.0", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []*glGit.FileChange{
+		{Kind: glGit.ChangeAdded, To: "LICENSE", AddedLines: 1},
+		{Kind: glGit.ChangeModified, From: "README.md", To: "README.md", AddedLines: 2, RemovedLines: 1},
+		{Kind: glGit.ChangeRenamed, From: "docs/guide.md", To: "docs/manual.md", AddedLines: 1},
+		{Kind: glGit.ChangeModified, From: "logo.png", To: "logo.png", Binary: true},
+		{Kind: glGit.ChangeDeleted, From: "main.go", RemovedLines: 1},
+	}, changes)
+	assert.Equal(t, "main.go", changes[4].Path())
+	assert.Equal(t, "D", changes[4].Kind.String())
+
+	changes, err = glGit.DiffRevisions(tr.path, "HEAD", "HEAD~1")
+	assert.NoError(t, err)
+	assert.Equal(t, &glGit.FileChange{Kind: glGit.ChangeAdded, To: "main.go", AddedLines: 1}, changes[4])
+
+	changes, err = glGit.DiffRevisions(tr.path, "HEAD", "HEAD")
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = glGit.DiffRevisions(tr.path, "missing", "HEAD")
+	assert.True(t, errors.Is(err, glGit.ErrRevisionNotFound))
+}
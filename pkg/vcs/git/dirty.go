@@ -0,0 +1,43 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// isWorktreeDirty checks if the worktree of the given repository has uncommitted changes.
+// Modified, staged and deleted files are always considered as uncommitted changes while untracked files are only taken
+// into account when explicitly enabled. Ignored files are never taken into account.
+// Bare repositories have no worktree and are therefore never dirty.
+func isWorktreeDirty(repo *git.Repository, untracked bool) (bool, error) {
+	wt, wtErr := repo.Worktree()
+	if wtErr != nil {
+		if errors.Is(wtErr, git.ErrIsBareRepository) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get worktree: %w", wtErr)
+	}
+
+	status, statusErr := wt.Status()
+	if statusErr != nil {
+		return false, fmt.Errorf("failed to get worktree status: %w", statusErr)
+	}
+	for _, fileStatus := range status {
+		if fileStatus.Staging == git.Untracked && fileStatus.Worktree == git.Untracked {
+			if untracked {
+				return true, nil
+			}
+			continue
+		}
+		if fileStatus.Staging != git.Unmodified || fileStatus.Worktree != git.Unmodified {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"encoding/json"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// versionData is the serializable representation of a version used for the JSON and YAML encodings.
+type versionData struct {
+	Version          string   `json:"version" yaml:"version"`
+	Branch           string   `json:"branch,omitempty" yaml:"branch,omitempty"`
+	BranchTip        bool     `json:"branchTip,omitempty" yaml:"branchTip,omitempty"`
+	Channel          string   `json:"channel,omitempty" yaml:"channel,omitempty"`
+	CommitHash       string   `json:"commitHash,omitempty" yaml:"commitHash,omitempty"`
+	CommitsAhead     int      `json:"commitsAhead,omitempty" yaml:"commitsAhead,omitempty"`
+	Detached         bool     `json:"detached,omitempty" yaml:"detached,omitempty"`
+	Dirty            bool     `json:"dirty,omitempty" yaml:"dirty,omitempty"`
+	HeadRef          string   `json:"headRef,omitempty" yaml:"headRef,omitempty"`
+	LatestVersionTag *tagData `json:"latestVersionTag,omitempty" yaml:"latestVersionTag,omitempty"`
+	Scheme           string   `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+}
+
+// tagData is the serializable representation of a tag reference.
+type tagData struct {
+	Hash string `json:"hash" yaml:"hash"`
+	Name string `json:"name" yaml:"name"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// The version is encoded as object with the semantic version as string and all version metadata as fields, except for
+// the signer of the latest Git version tag. The format of a CalVerScheme the version has been derived with is encoded
+// as "scheme" field so that the version is decoded in the same format.
+func (v Version) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.data())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *Version) UnmarshalJSON(data []byte) error {
+	var vd versionData
+	if err := json.Unmarshal(data, &vd); err != nil {
+		return err
+	}
+
+	return v.setData(&vd)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+// The version is encoded as semantic version string including the build metadata while all other version metadata is
+// omitted.
+func (v Version) MarshalText() ([]byte, error) {
+	if v.Version == nil {
+		return []byte{}, nil
+	}
+
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// Only the semantic version is set while all other version metadata is reset. Note that the version is always decoded
+// as SemVer version since the text encoding does not include the version scheme.
+func (v *Version) UnmarshalText(text []byte) error {
+	return v.setData(&versionData{Version: string(text)})
+}
+
+// MarshalYAML implements the Marshaler interface of the "gopkg.in/yaml.v2" and "gopkg.in/yaml.v3" modules.
+// The version is encoded like for the JSON encoding.
+func (v Version) MarshalYAML() (interface{}, error) {
+	return v.data(), nil
+}
+
+// UnmarshalYAML implements the Unmarshaler interface of the "gopkg.in/yaml.v2" module that is also supported by the
+// "gopkg.in/yaml.v3" module.
+func (v *Version) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var vd versionData
+	if err := unmarshal(&vd); err != nil {
+		return err
+	}
+
+	return v.setData(&vd)
+}
+
+// data returns the serializable representation of the version.
+func (v *Version) data() *versionData {
+	vd := &versionData{
+		Branch:       v.Branch,
+		BranchTip:    v.BranchTip,
+		Channel:      v.Channel,
+		CommitsAhead: v.CommitsAhead,
+		Detached:     v.Detached,
+		Dirty:        v.Dirty,
+		HeadRef:      v.HeadRef.String(),
+		Scheme:       schemeFormat(v.scheme),
+	}
+	if v.Version != nil {
+		vd.Version = v.String()
+	}
+	if !v.CommitHash.IsZero() {
+		vd.CommitHash = v.CommitHash.String()
+	}
+	if v.LatestVersionTag != nil {
+		vd.LatestVersionTag = &tagData{Hash: v.LatestVersionTag.Hash().String(), Name: v.LatestVersionTag.Name().String()}
+	}
+
+	return vd
+}
+
+// setData sets the version from the given serializable representation.
+func (v *Version) setData(vd *versionData) error {
+	scheme, schemeErr := parseSchemeFormat(vd.Scheme)
+	if schemeErr != nil {
+		return newError(ErrInvalidVersion, schemeErr, "invalid version scheme %q", vd.Scheme)
+	}
+	semVersion, semVerErr := scheme.Parse(vd.Version)
+	if semVerErr != nil {
+		return semVerErr
+	}
+	if vd.CommitHash != "" && !plumbing.IsHash(vd.CommitHash) {
+		return newError(ErrInvalidVersion, nil, "invalid commit hash %q", vd.CommitHash)
+	}
+	if vd.LatestVersionTag != nil && !plumbing.IsHash(vd.LatestVersionTag.Hash) {
+		return newError(ErrInvalidVersion, nil, "invalid hash %q of latest version tag", vd.LatestVersionTag.Hash)
+	}
+
+	*v = Version{
+		Version:      semVersion,
+		Branch:       vd.Branch,
+		BranchTip:    vd.BranchTip,
+		Channel:      vd.Channel,
+		CommitsAhead: vd.CommitsAhead,
+		CommitHash:   plumbing.NewHash(vd.CommitHash),
+		Detached:     vd.Detached,
+		Dirty:        vd.Dirty,
+		HeadRef:      plumbing.ReferenceName(vd.HeadRef),
+	}
+	if vd.Scheme != "" {
+		v.scheme = scheme
+	}
+	if vd.LatestVersionTag != nil {
+		v.LatestVersionTag = plumbing.NewHashReference(
+			plumbing.ReferenceName(vd.LatestVersionTag.Name),
+			plumbing.NewHash(vd.LatestVersionTag.Hash),
+		)
+	}
+
+	return nil
+}
+
+// schemeFormat returns the format of the given version scheme for the serializable representation, which is the format
+// of a CalVerScheme or an empty string for the SemVerScheme and custom schemes.
+func schemeFormat(scheme Scheme) string {
+	if calVer, ok := scheme.(*CalVerScheme); ok {
+		return calVer.String()
+	}
+
+	return ""
+}
+
+// parseSchemeFormat returns the version scheme for the given format of the serializable representation, which is a
+// CalVerScheme for a CalVer format or the SemVerScheme for an empty string.
+func parseSchemeFormat(format string) (Scheme, error) {
+	if format == "" {
+		return SemVerScheme{}, nil
+	}
+
+	return NewCalVerScheme(format)
+}
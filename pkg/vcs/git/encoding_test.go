@@ -0,0 +1,138 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+// deriveTestVersion derives a version from a test repository with a version tag and a commit ahead.
+func deriveTestVersion(t *testing.T) *glGit.Version {
+	t.Helper()
+	tr := newTestRepository(t)
+	tr.tag("v1.2.0", tr.commit("first"), "v1.2.0")
+	tr.commit("second")
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path)
+	if err != nil {
+		assert.FailNow(t, "failed to derive version", "error: %v", err)
+	}
+
+	return version
+}
+
+func TestVersion_JSON(t *testing.T) {
+	version := deriveTestVersion(t)
+
+	data, err := json.Marshal(version)
+	assert.NoError(t, err)
+	var fields map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(data, &fields)) {
+		assert.Equal(t, version.String(), fields["version"])
+		assert.Equal(t, "master", fields["branch"])
+		assert.Equal(t, version.CommitHash.String(), fields["commitHash"])
+		assert.Equal(t, float64(1), fields["commitsAhead"])
+		assert.NotContains(t, fields, "dirty")
+	}
+
+	var decoded glGit.Version
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, version.String(), decoded.String())
+	assert.Equal(t, version.Branch, decoded.Branch)
+	assert.Equal(t, version.BranchTip, decoded.BranchTip)
+	assert.Equal(t, version.CommitHash, decoded.CommitHash)
+	assert.Equal(t, version.CommitsAhead, decoded.CommitsAhead)
+	assert.Equal(t, version.HeadRef, decoded.HeadRef)
+	assert.Equal(t, version.LatestVersionTag.Name(), decoded.LatestVersionTag.Name())
+	assert.Equal(t, version.LatestVersionTag.Hash(), decoded.LatestVersionTag.Hash())
+
+	// The encoding must be the same for values and pointers.
+	valueData, err := json.Marshal(*version)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(data), string(valueData))
+
+	err = json.Unmarshal([]byte(`{"version":"invalid"}`), &decoded)
+	assert.True(t, errors.Is(err, glGit.ErrInvalidVersion))
+	err = json.Unmarshal([]byte(`{"version":"1.0.0","commitHash":"invalid"}`), &decoded)
+	assert.True(t, errors.Is(err, glGit.ErrInvalidVersion))
+}
+
+func TestVersion_Text(t *testing.T) {
+	version := deriveTestVersion(t)
+
+	text, err := version.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, version.String(), string(text))
+
+	var decoded glGit.Version
+	assert.NoError(t, decoded.UnmarshalText(text))
+	assert.Equal(t, version.String(), decoded.String())
+	assert.Empty(t, decoded.Branch)
+	assert.True(t, decoded.CommitHash.IsZero())
+
+	assert.Error(t, decoded.UnmarshalText([]byte("invalid")))
+}
+
+func TestVersion_YAML(t *testing.T) {
+	version := deriveTestVersion(t)
+
+	data, err := yaml.Marshal(version)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "version: "+version.String())
+	assert.Contains(t, string(data), "commitHash: "+version.CommitHash.String())
+
+	var decoded glGit.Version
+	assert.NoError(t, yaml.Unmarshal(data, &decoded))
+	assert.Equal(t, version.String(), decoded.String())
+	assert.Equal(t, version.Branch, decoded.Branch)
+	assert.Equal(t, version.CommitHash, decoded.CommitHash)
+	assert.Equal(t, version.CommitsAhead, decoded.CommitsAhead)
+	assert.Equal(t, version.LatestVersionTag.Name(), decoded.LatestVersionTag.Name())
+}
+
+func TestVersion_CalVerRoundTrip(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.tag("2026.01.0", tr.commit("first"), "2026.01.0")
+	tr.commit("second")
+	version, err := glGit.DeriveVersion("2026.01.0", tr.path, glGit.WithScheme(newTestCalVerScheme(t, "YYYY.0M.MICRO")))
+	if err != nil {
+		assert.FailNow(t, "failed to derive version", "error: %v", err)
+	}
+	assert.Regexp(t, `^2026\.01\.0\+1\.`, version.String())
+
+	data, err := json.Marshal(version)
+	assert.NoError(t, err)
+	var decoded glGit.Version
+	if assert.NoError(t, json.Unmarshal(data, &decoded)) {
+		assert.Equal(t, version.String(), decoded.String())
+	}
+
+	data, err = yaml.Marshal(version)
+	assert.NoError(t, err)
+	decoded = glGit.Version{}
+	if assert.NoError(t, yaml.Unmarshal(data, &decoded)) {
+		assert.Equal(t, version.String(), decoded.String())
+	}
+
+	vars := glGit.BuildVars{Scheme: "main.scheme", Version: "main.version"}
+	assert.Equal(t, "-X main.version="+version.String()+" -X main.scheme=YYYY.0M.MICRO", version.LDFlags(vars))
+	read, err := glGit.ReadBuildVersion("2026.01.0", glGit.BuildVars{Scheme: "YYYY.0M.MICRO", Version: version.String()})
+	if assert.NoError(t, err) {
+		assert.Equal(t, version.String(), read.String())
+	}
+	read, err = glGit.ReadBuildVersion("2026.01.0", glGit.BuildVars{Scheme: "YYYY.0M.MICRO"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2026.01.0", read.String())
+	}
+
+	err = json.Unmarshal([]byte(`{"version":"2026.01.0","scheme":"invalid"}`), &decoded)
+	assert.True(t, errors.Is(err, glGit.ErrInvalidVersion))
+}
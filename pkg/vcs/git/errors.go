@@ -0,0 +1,87 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrEmptyRepository is the error returned when a repository has no commits yet.
+	// Note that HEAD of an empty repository always points to an unborn branch, so errors wrapping ErrEmptyRepository
+	// also match ErrUnbornHead.
+	ErrEmptyRepository = errors.New("repository has no commits")
+
+	// ErrInvalidDefaultVersion is the error returned when the default version is empty or not a valid SemVer version.
+	ErrInvalidDefaultVersion = errors.New("invalid default version")
+
+	// ErrInvalidModule is the error returned when the tag prefix cannot be derived from the module directory, e.g.
+	// because it is not within the repository or contains no valid Go module file.
+	ErrInvalidModule = errors.New("invalid Go module")
+
+	// ErrInvalidOption is the error returned when an option has an invalid value.
+	ErrInvalidOption = errors.New("invalid option")
+
+	// ErrInvalidVersion is the error returned when a version is not a valid SemVer version.
+	ErrInvalidVersion = errors.New("invalid version")
+
+	// ErrRepositoryNotFound is the error returned when no repository exists at the given path or, when the root
+	// directory of the repository is searched, in any of its parent directories.
+	ErrRepositoryNotFound = errors.New("repository not found")
+
+	// ErrRevisionNotFound is the error returned when a revision cannot be resolved to a commit.
+	ErrRevisionNotFound = errors.New("revision not found")
+
+	// ErrTagVersionNotIncreasing is the error returned when a version tag should be created whose version is not higher
+	// than the version of the latest existing version tag.
+	ErrTagVersionNotIncreasing = errors.New("version is not higher than the version of the latest version tag")
+
+	// ErrVersionConstraint is the error returned when a derived version does not satisfy the version constraint of the
+	// branch HEAD is pointing to.
+	ErrVersionConstraint = errors.New("version does not satisfy branch constraint")
+
+	// ErrUnbornHead is the error returned when HEAD points to a branch that has no commits yet, e.g. in an empty
+	// repository or after an orphan branch has been checked out.
+	ErrUnbornHead = errors.New("HEAD points to an unborn branch")
+)
+
+// Error is an error whose failure class is identified by a sentinel error, like ErrRepositoryNotFound, that can
+// additionally wrap the underlying error, like an error of the "github.com/go-git/go-git/v5" module.
+// Both can be inspected through the errors.Is and errors.As functions of the "errors" Go standard library package.
+type Error struct {
+	// Err is the underlying error, or nil if there is none.
+	Err error
+
+	// Kind is the sentinel error that identifies the failure class.
+	Kind error
+
+	// Msg describes the failure.
+	Msg string
+}
+
+// newError creates a new error of the given failure class that wraps the given underlying error, which can be nil,
+// with a message formatted according to the given format specifier.
+func newError(kind, err error, format string, args ...interface{}) *Error {
+	return &Error{Err: err, Kind: kind, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%v: %s", e.Kind, e.Msg)
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+
+	return msg
+}
+
+// Is checks if the given target error is the sentinel error that identifies the failure class.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind || (e.Kind == ErrEmptyRepository && target == ErrUnbornHead)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
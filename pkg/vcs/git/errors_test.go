@@ -0,0 +1,110 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+func TestDeriveVersion_FailWithEmptyRepository(t *testing.T) {
+	tr := newTestRepository(t)
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path)
+
+	assert.Nil(t, version)
+	assert.True(t, errors.Is(err, glGit.ErrEmptyRepository))
+	assert.True(t, errors.Is(err, glGit.ErrUnbornHead))
+}
+
+func TestDeriveVersion_FailWithUnbornHead(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.commit("first")
+	orphanRef := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("orphan"))
+	if err := tr.repo.Storer.SetReference(orphanRef); err != nil {
+		assert.FailNow(t, "failed to check out orphan branch", "error: %v", err)
+	}
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path)
+
+	assert.Nil(t, version)
+	assert.True(t, errors.Is(err, glGit.ErrUnbornHead))
+	assert.False(t, errors.Is(err, glGit.ErrEmptyRepository))
+}
+
+func TestDeriveVersion_FailWithRepositoryNotFound(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := glGit.DeriveVersion(testDefaultVersion, dir)
+	assert.True(t, errors.Is(err, glGit.ErrRepositoryNotFound))
+	assert.True(t, errors.Is(err, git.ErrRepositoryNotExists))
+
+	_, err = glGit.DeriveVersion(testDefaultVersion, filepath.Join(dir, "missing"), glGit.WithDetectDotGit(true))
+	assert.True(t, errors.Is(err, glGit.ErrRepositoryNotFound))
+
+	_, err = glGit.DeriveVersionFromRepository(nil, testDefaultVersion)
+	assert.True(t, errors.Is(err, glGit.ErrRepositoryNotFound))
+}
+
+func TestDeriveVersion_FailWithInvalidInput(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.commit("first")
+
+	testCases := []struct {
+		defaultVersion string
+		kind           error
+		name           string
+		opts           []glGit.Option
+	}{
+		{defaultVersion: "invalid", name: "invalid default version", kind: glGit.ErrInvalidDefaultVersion},
+		{
+			defaultVersion: testDefaultVersion,
+			kind:           glGit.ErrInvalidOption,
+			name:           "negative candidates",
+			opts:           []glGit.Option{glGit.WithCandidates(-1)},
+		},
+		{
+			defaultVersion: testDefaultVersion,
+			kind:           glGit.ErrInvalidOption,
+			name:           "invalid match pattern",
+			opts:           []glGit.Option{glGit.WithMatchPatterns("[")},
+		},
+		{
+			defaultVersion: testDefaultVersion,
+			kind:           glGit.ErrInvalidModule,
+			name:           "module directory outside of repository",
+			opts:           []glGit.Option{glGit.WithModuleDir(t.TempDir())},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			version, err := glGit.DeriveVersion(tc.defaultVersion, tr.path, tc.opts...)
+
+			assert.Nil(t, version)
+			assert.True(t, errors.Is(err, tc.kind), "error: %v", err)
+			var glGitErr *glGit.Error
+			if assert.True(t, errors.As(err, &glGitErr)) {
+				assert.Equal(t, tc.kind, glGitErr.Kind)
+			}
+		})
+	}
+}
+
+func TestPseudoVersion_FailWithRevisionNotFound(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.commit("first")
+
+	_, err := glGit.PseudoVersion(tr.path, "missing")
+
+	assert.True(t, errors.Is(err, glGit.ErrRevisionNotFound))
+	assert.True(t, errors.Is(err, plumbing.ErrReferenceNotFound))
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+)
+
+// maxGraphCandidates is the maximum amount of tag candidates that can be tracked during a graph traversal.
+// Each candidate is represented by a single bit of a commit's reachability flags.
+const maxGraphCandidates = 63
+
+// graphCommit is a commit visited during a graph traversal.
+type graphCommit struct {
+	node commitgraph.CommitNode
+
+	// flags are the bits of all tag candidates the commit is reachable from.
+	flags uint64
+
+	// seq is the insertion sequence number used to keep the queue order stable for commits with the same committer time.
+	seq int
+}
+
+// commitQueue is a priority queue of commits ordered by committer time, newest first.
+// Commits with the same committer time are kept in insertion order like the "commit_list_insert_by_date" function of
+// Git.
+type commitQueue []*graphCommit
+
+func (q commitQueue) Len() int { return len(q) }
+
+func (q commitQueue) Less(i, j int) bool {
+	ti, tj := q[i].node.CommitTime(), q[j].node.CommitTime()
+	if ti.Equal(tj) {
+		return q[i].seq < q[j].seq
+	}
+	return ti.After(tj)
+}
+
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x interface{}) {
+	gc, _ := x.(*graphCommit)
+	*q = append(*q, gc)
+}
+
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	gc := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return gc
+}
+
+// graphCandidate is a tag candidate found during a graph traversal.
+type graphCandidate struct {
+	*tagCandidate
+
+	// depth is the amount of visited commits that are not reachable from the tag.
+	depth int
+
+	// flag is the bit that marks commits reachable from the tag.
+	flag uint64
+
+	// foundOrder is the order in which the candidate has been found.
+	foundOrder int
+}
+
+// graphWalker traverses the commit graph in committer time order like the "describe" command of Git.
+type graphWalker struct {
+	commits     map[plumbing.Hash]*graphCommit
+	firstParent bool
+	index       commitgraph.CommitNodeIndex
+	progress    *progress
+	queue       commitQueue
+	seq         int
+}
+
+// push adds the commit to the queue and merges the given flags into the flags of the commit.
+// Commits that have already been visited only get their flags updated.
+func (w *graphWalker) push(node commitgraph.CommitNode, flags uint64) {
+	if gc, ok := w.commits[node.ID()]; ok {
+		gc.flags |= flags
+		return
+	}
+	gc := &graphCommit{node: node, flags: flags, seq: w.seq}
+	w.seq++
+	w.commits[node.ID()] = gc
+	heap.Push(&w.queue, gc)
+}
+
+// pushParents adds the parents of the given commit to the queue and propagates its flags.
+func (w *graphWalker) pushParents(gc *graphCommit) error {
+	for i, parentHash := range gc.node.ParentHashes() {
+		if i > 0 && w.firstParent {
+			break
+		}
+		if parent, ok := w.commits[parentHash]; ok {
+			parent.flags |= gc.flags
+			continue
+		}
+		parent, parentErr := w.index.Get(parentHash)
+		if parentErr != nil {
+			return fmt.Errorf("failed to get parent commit %s of commit %s: %w", parentHash, gc.node.ID(), parentErr)
+		}
+		w.push(parent, gc.flags)
+	}
+
+	return nil
+}
+
+// pop removes the newest commit from the queue.
+// An error is returned when the derivation has been canceled.
+func (w *graphWalker) pop() (*graphCommit, error) {
+	gc, _ := heap.Pop(&w.queue).(*graphCommit)
+	if progressErr := w.progress.commit(gc.node.ID()); progressErr != nil {
+		return nil, progressErr
+	}
+	return gc, nil
+}
+
+// describeGraph searches for the tag candidate that describes the commit with the given hash best by traversing the
+// commit graph like the "describe" command of Git.
+// The distance of a candidate is the amount of commits that are reachable from the described commit, but not from the
+// tagged commit. When the first-parent traversal mode is used, only the first parent of merge commits is followed.
+func describeGraph(
+	index commitgraph.CommitNodeIndex,
+	commitHash plumbing.Hash,
+	tags map[plumbing.Hash]*tagCandidate,
+	opt *Options,
+	p *progress,
+) (*tagCandidate, error) {
+	maxCandidates := opt.Candidates
+	if maxCandidates > maxGraphCandidates {
+		maxCandidates = maxGraphCandidates
+	}
+
+	head, headErr := index.Get(commitHash)
+	if headErr != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", commitHash, headErr)
+	}
+	w := &graphWalker{
+		commits:     make(map[plumbing.Hash]*graphCommit),
+		firstParent: opt.TraversalMode == TraversalFirstParent,
+		index:       index,
+		progress:    p,
+	}
+	w.push(head, 0)
+
+	var candidates []*graphCandidate
+	var gaveUpOn *graphCommit
+	seenCommits := 0
+	for w.queue.Len() > 0 {
+		gc, popErr := w.pop()
+		if popErr != nil {
+			return nil, popErr
+		}
+		seenCommits++
+
+		if tag, ok := tags[gc.node.ID()]; ok {
+			if len(candidates) >= maxCandidates {
+				gaveUpOn = gc
+				break
+			}
+			candidate := &graphCandidate{
+				tagCandidate: tag,
+				depth:        seenCommits - 1,
+				flag:         1 << uint(len(candidates)),
+				foundOrder:   len(candidates),
+			}
+			candidates = append(candidates, candidate)
+			gc.flags |= candidate.flag
+		}
+
+		for _, candidate := range candidates {
+			if gc.flags&candidate.flag == 0 {
+				candidate.depth++
+			}
+		}
+
+		// Stop when the last remaining path is already covered by the best candidates.
+		if len(candidates) > 0 && w.queue.Len() == 0 {
+			bestDepth, bestFlags := -1, uint64(0)
+			for _, candidate := range candidates {
+				switch {
+				case bestDepth < 0 || candidate.depth < bestDepth:
+					bestDepth, bestFlags = candidate.depth, candidate.flag
+				case candidate.depth == bestDepth:
+					bestFlags |= candidate.flag
+				}
+			}
+			if gc.flags&bestFlags == bestFlags {
+				break
+			}
+		}
+
+		if parentsErr := w.pushParents(gc); parentsErr != nil {
+			return nil, parentsErr
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].depth != candidates[j].depth {
+			return candidates[i].depth < candidates[j].depth
+		}
+		return candidates[i].foundOrder < candidates[j].foundOrder
+	})
+
+	best := candidates[0]
+	if gaveUpOn != nil {
+		heap.Push(&w.queue, gaveUpOn)
+	}
+	if finishErr := w.finishDepth(best); finishErr != nil {
+		return nil, finishErr
+	}
+	best.distance = best.depth
+
+	return best.tagCandidate, nil
+}
+
+// finishDepth continues the traversal to count all remaining commits that are not reachable from the given candidate
+// until all commits left in the queue are reachable from it.
+func (w *graphWalker) finishDepth(best *graphCandidate) error {
+	for w.queue.Len() > 0 {
+		gc, popErr := w.pop()
+		if popErr != nil {
+			return popErr
+		}
+		if gc.flags&best.flag != 0 {
+			covered := true
+			for _, queued := range w.queue {
+				if queued.flags&best.flag == 0 {
+					covered = false
+					break
+				}
+			}
+			if covered {
+				break
+			}
+		} else {
+			best.depth++
+		}
+		if parentsErr := w.pushParents(gc); parentsErr != nil {
+			return parentsErr
+		}
+	}
+
+	return nil
+}
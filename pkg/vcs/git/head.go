@@ -0,0 +1,106 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// headState stores information about the state of HEAD.
+type headState struct {
+	// branch is the short name of the branch HEAD is pointing to, or empty when HEAD is detached.
+	branch string
+
+	// branchTip indicates whether HEAD is pointing to the tip of a local or remote-tracking branch.
+	branchTip bool
+
+	// detached indicates whether HEAD is pointing directly to a commit instead of a branch.
+	detached bool
+
+	// hash is the hash of the commit HEAD is pointing to.
+	hash plumbing.Hash
+
+	// ref is the name of the reference HEAD is symbolically pointing to, or "HEAD" when detached.
+	ref plumbing.ReferenceName
+}
+
+// resolveHead resolves the state of HEAD of the given repository.
+// Unlike searching for a branch that is pointing to the same commit, this also works when HEAD is detached, like in
+// most CI checkouts, or when multiple branches are pointing to the same commit.
+func resolveHead(repo *git.Repository) (*headState, error) {
+	headRef, headRefErr := repo.Reference(plumbing.HEAD, false)
+	if headRefErr != nil {
+		return nil, fmt.Errorf("failed to get the HEAD reference: %w", headRefErr)
+	}
+	resolvedHeadRef, repoHeadErr := repo.Head()
+	switch {
+	case errors.Is(repoHeadErr, plumbing.ErrReferenceNotFound):
+		return nil, unbornHeadError(repo, headRef)
+	case repoHeadErr != nil:
+		return nil, fmt.Errorf("failed to get the reference where HEAD is pointing to: %w", repoHeadErr)
+	}
+
+	state := &headState{hash: resolvedHeadRef.Hash(), ref: plumbing.HEAD}
+	if headRef.Type() == plumbing.SymbolicReference && headRef.Target().IsBranch() {
+		state.branch = headRef.Target().Short()
+		state.branchTip = true
+		state.ref = headRef.Target()
+		return state, nil
+	}
+
+	// When HEAD is detached, check if it is nonetheless pointing to the tip of a branch.
+	state.detached = true
+	refs, refsErr := repo.References()
+	if refsErr != nil {
+		return nil, fmt.Errorf("failed to get references: %w", refsErr)
+	}
+	defer refs.Close()
+	refIterErr := refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || !(ref.Name().IsBranch() || ref.Name().IsRemote()) {
+			return nil
+		}
+		if ref.Hash() == state.hash {
+			state.branchTip = true
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if refIterErr != nil {
+		return nil, fmt.Errorf("failed to iterate over references: %w", refIterErr)
+	}
+
+	return state, nil
+}
+
+// unbornHeadError returns the error for the given HEAD reference that points to a branch without commits.
+// The error wraps ErrEmptyRepository when the repository has no references to any commit at all, otherwise
+// ErrUnbornHead, like after an orphan branch has been checked out.
+func unbornHeadError(repo *git.Repository, headRef *plumbing.Reference) error {
+	refs, refsErr := repo.References()
+	if refsErr != nil {
+		return fmt.Errorf("failed to get references: %w", refsErr)
+	}
+	defer refs.Close()
+	empty := true
+	refIterErr := refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			empty = false
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if refIterErr != nil {
+		return fmt.Errorf("failed to iterate over references: %w", refIterErr)
+	}
+
+	if empty {
+		return newError(ErrEmptyRepository, nil, "HEAD points to unborn branch %s", headRef.Target().Short())
+	}
+	return newError(ErrUnbornHead, nil, "branch %s has no commits", headRef.Target().Short())
+}
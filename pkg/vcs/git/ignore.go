@@ -0,0 +1,225 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"bufio"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitConfig "github.com/go-git/go-git/v5/plumbing/format/config"
+
+	glFS "github.com/svengreb/golib/pkg/io/fs"
+	"github.com/svengreb/golib/pkg/vcs/git/attributes"
+)
+
+// IgnoreFileName is the name of Git ignore files.
+const IgnoreFileName = ".gitignore"
+
+// IgnoreOptions are options to skip paths that are ignored by Git.
+type IgnoreOptions struct {
+	// ExportIgnore indicates whether paths with the "export-ignore" Git attribute are skipped like the Git "archive"
+	// command does.
+	ExportIgnore bool
+
+	// GlobalExcludes indicates whether the patterns of the global excludes file are taken into account, which is the
+	// file configured through the "core.excludesFile" option of the global Git configuration or, when not configured,
+	// the "git/ignore" file in the XDG configuration directory of the user.
+	GlobalExcludes bool
+}
+
+// ignorePattern is a pattern of a Git ignore file.
+type ignorePattern struct {
+	// negated indicates whether the pattern starts with an exclamation mark and therefore re-includes matching paths.
+	negated bool
+
+	// pattern is the path pattern.
+	pattern *attributes.Pattern
+}
+
+// IgnoreSkipFunc creates a function for the "fs.Walk" function of the "github.com/svengreb/golib/pkg/io/fs" package
+// that skips all paths that are ignored by Git when walking the given root directory.
+// The root directory is treated as the root directory of the project whose ".gitignore" files, the "info/exclude" file
+// of its ".git" directory and, when enabled, the global excludes file are taken into account with the same precedence
+// and pattern semantics as Git, including negated patterns that cannot re-include paths whose parent directory is
+// ignored. The ".git" directory and nested repositories, like submodules, are always skipped.
+// The ignore files are read while walking so that the function can only be used for a single walk of the root
+// directory. Nil ignore options are the same as the zero value.
+func IgnoreSkipFunc(root string, ignoreOpts *IgnoreOptions) (glFS.SkipFunc, error) {
+	if ignoreOpts == nil {
+		ignoreOpts = &IgnoreOptions{}
+	}
+
+	var base []*ignorePattern
+	if ignoreOpts.GlobalExcludes {
+		globalPatterns, globalErr := readIgnoreFile(globalExcludesFile(), "")
+		if globalErr != nil {
+			return nil, globalErr
+		}
+		base = append(base, globalPatterns...)
+	}
+	excludePatterns, excludeErr := readIgnoreFile(filepath.Join(root, git.GitDirName, "info", "exclude"), "")
+	if excludeErr != nil {
+		return nil, excludeErr
+	}
+	base = append(base, excludePatterns...)
+	rootPatterns, rootErr := readIgnoreFile(filepath.Join(root, IgnoreFileName), "")
+	if rootErr != nil {
+		return nil, rootErr
+	}
+
+	var exportAttrs *attributes.Matcher
+	if ignoreOpts.ExportIgnore {
+		var loadErr error
+		if exportAttrs, loadErr = attributes.Load(root); loadErr != nil {
+			return nil, loadErr
+		}
+	}
+
+	// The patterns that apply to the entries of a directory by its slash-separated path relative to the root directory.
+	dirPatterns := map[string][]*ignorePattern{"": append(base, rootPatterns...)}
+	return func(name string, d iofs.DirEntry) (bool, error) {
+		parent := path.Dir(name)
+		if parent == "." {
+			parent = ""
+		}
+		if d.IsDir() && (d.Name() == git.GitDirName || isNestedRepository(filepath.Join(root, filepath.FromSlash(name)))) {
+			return true, nil
+		}
+		if isIgnored(dirPatterns[parent], name, d.IsDir()) ||
+			(exportAttrs != nil && exportAttrs.Match(name, d.IsDir()).IsSet(attributes.ExportIgnore)) {
+			return true, nil
+		}
+
+		if d.IsDir() {
+			patterns, readErr := readIgnoreFile(filepath.Join(root, filepath.FromSlash(name), IgnoreFileName), name)
+			if readErr != nil {
+				return false, readErr
+			}
+			inherited := dirPatterns[parent]
+			dirPatterns[name] = append(inherited[:len(inherited):len(inherited)], patterns...)
+		}
+
+		return false, nil
+	}, nil
+}
+
+// isIgnored checks if the given slash-separated path relative to the root directory is ignored by the given patterns,
+// which is decided by the last matching pattern.
+func isIgnored(patterns []*ignorePattern, name string, isDir bool) bool {
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if patterns[i].pattern.Match(name, isDir) {
+			return !patterns[i].negated
+		}
+	}
+
+	return false
+}
+
+// isNestedRepository checks if the given directory is the root directory of a nested repository.
+func isNestedRepository(dir string) bool {
+	_, err := os.Lstat(filepath.Join(dir, git.GitDirName))
+	return err == nil
+}
+
+// globalExcludesFile returns the path of the global excludes file, or an empty string if it cannot be determined.
+// Like Git, the "core.excludesFile" option of the "~/.gitconfig" file takes precedence over the option of the
+// "git/config" file in the XDG configuration directory of the user.
+func globalExcludesFile() string {
+	home, homeErr := os.UserHomeDir()
+	if homeErr != nil {
+		return ""
+	}
+	xdgConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	if xdgConfigHome == "" {
+		xdgConfigHome = filepath.Join(home, ".config")
+	}
+
+	excludesFile := filepath.Join(xdgConfigHome, "git", "ignore")
+	for _, configFile := range []string{filepath.Join(xdgConfigHome, "git", "config"), filepath.Join(home, ".gitconfig")} {
+		f, openErr := os.Open(configFile)
+		if openErr != nil {
+			continue
+		}
+		cfg := gitConfig.New()
+		decodeErr := gitConfig.NewDecoder(f).Decode(cfg)
+		_ = f.Close()
+		if decodeErr != nil {
+			continue
+		}
+		if value := cfg.Section("core").Options.Get("excludesFile"); value != "" {
+			excludesFile = value
+			if strings.HasPrefix(value, "~/") {
+				excludesFile = filepath.Join(home, value[2:])
+			}
+		}
+	}
+
+	return excludesFile
+}
+
+// readIgnoreFile reads the patterns of the Git ignore file at the given path for the given slash-separated directory
+// relative to the root directory.
+// Like Git, no patterns are returned when the file does not exist or is no regular file, e.g. a symbolic link.
+func readIgnoreFile(name, dir string) ([]*ignorePattern, error) {
+	if name == "" {
+		return nil, nil
+	}
+	info, statErr := os.Lstat(name)
+	if statErr != nil || !info.Mode().IsRegular() {
+		return nil, nil
+	}
+
+	f, openErr := os.Open(name)
+	if openErr != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", name, openErr)
+	}
+	defer func() { _ = f.Close() }()
+
+	var patterns []*ignorePattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if p, ok := newIgnorePattern(scanner.Text(), dir); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", name, scanErr)
+	}
+
+	return patterns, nil
+}
+
+// newIgnorePattern parses a line of a Git ignore file in the given directory.
+// It returns false when the line is a comment or contains no pattern.
+func newIgnorePattern(line, dir string) (*ignorePattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, false
+	}
+	// Trailing spaces are ignored unless they are escaped with a backslash.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+
+	var negated bool
+	switch {
+	case strings.HasPrefix(line, "!"):
+		negated = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	p, ok := attributes.NewPattern(line, dir)
+	if !ok {
+		return nil, false
+	}
+
+	return &ignorePattern{negated: negated, pattern: p}, true
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	iofs "io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	glFS "github.com/svengreb/golib/pkg/io/fs"
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+// walkFiles walks the root directory of the repository while skipping ignored paths and returns the slash-separated
+// paths of all visited files.
+func (tr *testRepository) walkFiles(ignoreOpts *glGit.IgnoreOptions) []string {
+	tr.t.Helper()
+	skip, err := glGit.IgnoreSkipFunc(tr.path, ignoreOpts)
+	if err != nil {
+		assert.FailNow(tr.t, "failed to create ignore skip function", "error: %v", err)
+	}
+
+	var files []string
+	walkErr := glFS.Walk(tr.path, func(p string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, relErr := filepath.Rel(tr.path, p)
+			if relErr != nil {
+				return relErr
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	}, glFS.WithSkipFuncs(skip))
+	if walkErr != nil {
+		assert.FailNow(tr.t, "failed to walk directory", "error: %v", walkErr)
+	}
+
+	return files
+}
+
+func TestIgnoreSkipFunc(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	homeRepo := &testRepository{path: home, t: t}
+	homeRepo.writeFile(".gitconfig", "[core]\n\texcludesFile = ~/global-ignore\n")
+	homeRepo.writeFile("global-ignore", "*.swp\n")
+	homeRepo.writeFile(".config/git/ignore", "*.unused\n")
+
+	tr := newTestRepository(t)
+	for name, content := range map[string]string{
+		".git/info/exclude": "/local/\n",
+		".gitignore": `# Comments and blank lines are ignored.
+
+*.log
+!important.log
+/build/
+docs/**
+!docs/keep.md
+\#hash
+trailing.txt   
+ignored/
+`,
+		"#hash":                 "",
+		"trailing.txt":          "",
+		"main.go":               "",
+		"main.go.swp":           "",
+		"a.unused":              "",
+		"debug.log":             "",
+		"important.log":         "",
+		"build/out":             "",
+		"pkg/build/out":         "",
+		"docs/index.md":         "",
+		"docs/keep.md":          "",
+		"local/notes.txt":       "",
+		"vendor/.gitignore":     "*\n!*/\n!*.go\n",
+		"vendor/a/a.go":         "",
+		"vendor/a/a.txt":        "",
+		"logs/.gitignore":       "",
+		"logs/app.log":          "",
+		"submodule/.git":        "gitdir: ../.git/modules/submodule\n",
+		"submodule/main.go":     "",
+		"export/.gitignore":     "",
+		"export/skipped.go":     "",
+		".gitattributes":        "/export export-ignore\n*.gen.go export-ignore\n",
+		"api.gen.go":            "",
+		"ignored/.gitignore":    "!ignored.txt\n",
+		"ignored/ignored.txt":   "",
+		".gitignore-ignored":    "",
+		"pkg/.gitignore":        "/only-here.txt\n",
+		"pkg/only-here.txt":     "",
+		"pkg/sub/only-here.txt": "",
+	} {
+		tr.writeFile(name, content)
+	}
+
+	// The global excludes file is only taken into account when enabled.
+	files := tr.walkFiles(nil)
+	assert.Contains(t, files, "main.go.swp")
+	assert.Contains(t, files, "a.unused")
+
+	assert.Equal(t, []string{
+		".gitattributes",
+		".gitignore",
+		".gitignore-ignored",
+		"a.unused",
+		"api.gen.go",
+		"docs/keep.md",
+		"export/.gitignore",
+		"export/skipped.go",
+		"important.log",
+		"logs/.gitignore",
+		"main.go",
+		"pkg/.gitignore",
+		// Anchored patterns only match relative to the directory of the ignore file.
+		"pkg/build/out",
+		"pkg/sub/only-here.txt",
+		// Negated patterns re-include directories and files that are ignored by a previous pattern.
+		"vendor/a/a.go",
+	}, tr.walkFiles(&glGit.IgnoreOptions{GlobalExcludes: true}))
+
+	files = tr.walkFiles(&glGit.IgnoreOptions{ExportIgnore: true})
+	assert.NotContains(t, files, "export/skipped.go")
+	assert.NotContains(t, files, "api.gen.go")
+	assert.Contains(t, files, "main.go")
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// BuildVars are string variables of a Go binary that store version information.
+// When rendering linker flags, each field is the fully qualified name of a variable, like "main.version" or
+// "github.com/svengreb/golib/internal/build.version", while variables with an empty name are omitted.
+// When reading the version at runtime, each field is the value of the variable that has been set through the linker
+// flags.
+type BuildVars struct {
+	// Branch is the variable for the short name of the branch HEAD is pointing to.
+	Branch string
+
+	// CommitHash is the variable for the hash of the commit HEAD is pointing to.
+	CommitHash string
+
+	// Dirty is the variable that indicates whether the worktree has uncommitted changes, either "true" or "false".
+	Dirty string
+
+	// Scheme is the variable for the format of the CalVerScheme the version has been derived with, so that the version
+	// is read in the same format. It is omitted when rendering linker flags for versions of other schemes.
+	Scheme string
+
+	// Version is the variable for the semantic version including the build metadata.
+	Version string
+}
+
+// LDFlags renders the linker flags that set the given variables to the values of the version.
+// The returned string can be passed to the "-ldflags" flag of the Go "build" command, e.g.
+// "-X main.version=1.2.3+4.d1a0e8f3 -X main.commit=d1a0e8f3...". Flags whose value contains whitespace are quoted.
+func (v *Version) LDFlags(vars BuildVars) string {
+	var flags []string
+	add := func(name, value string) {
+		if name == "" {
+			return
+		}
+		flag := fmt.Sprintf("-X %s=%s", name, value)
+		if strings.ContainsAny(value, " \t\n") {
+			flag = fmt.Sprintf("-X '%s=%s'", name, value)
+		}
+		flags = append(flags, flag)
+	}
+
+	if v.Version != nil {
+		add(vars.Version, v.String())
+	}
+	if !v.CommitHash.IsZero() {
+		add(vars.CommitHash, v.CommitHash.String())
+	}
+	add(vars.Branch, v.Branch)
+	add(vars.Dirty, strconv.FormatBool(v.Dirty))
+	if format := schemeFormat(v.scheme); format != "" {
+		add(vars.Scheme, format)
+	}
+
+	return strings.Join(flags, " ")
+}
+
+// ReadBuildVersion reads the version of the running binary from the given values of variables that have been set
+// through the linker flags rendered by Version.LDFlags.
+// When no version has been set, a best-effort version is reconstructed from the VCS information that is embedded in
+// the binary since Go 1.18, consisting of the module version, the commit hash and the state of the worktree. The given
+// default version is used when the module version is not a SemVer version, like for binaries built within the module
+// itself. The branch is not included in the embedded VCS information and therefore always empty.
+// The default version and the module version must be in the format of the CalVerScheme when the Scheme variable is set.
+func ReadBuildVersion(defaultVersion string, values BuildVars) (*Version, error) {
+	if values.Version == "" {
+		if !isVersion(defaultVersion, values.Scheme) {
+			return nil, newError(ErrInvalidDefaultVersion, nil, "failed to parse default version %q", defaultVersion)
+		}
+		return readBuildInfo(defaultVersion, values.Scheme)
+	}
+
+	vd := &versionData{
+		Branch:     values.Branch,
+		CommitHash: values.CommitHash,
+		Scheme:     values.Scheme,
+		Version:    values.Version,
+	}
+	if values.Dirty != "" {
+		dirty, parseErr := strconv.ParseBool(values.Dirty)
+		if parseErr != nil {
+			return nil, newError(ErrInvalidVersion, parseErr, "failed to parse dirty state %q", values.Dirty)
+		}
+		vd.Dirty = dirty
+	}
+	if values.Branch != "" {
+		vd.HeadRef = plumbing.NewBranchReferenceName(values.Branch).String()
+		vd.BranchTip = true
+	}
+
+	return newBuildInfoVersion(vd)
+}
+
+// newBuildInfoVersion creates a new version from the given serializable representation of build information.
+func newBuildInfoVersion(vd *versionData) (*Version, error) {
+	version := &Version{}
+	if setErr := version.setData(vd); setErr != nil {
+		return nil, setErr
+	}
+
+	return version, nil
+}
+
+// isVersion checks if the given version is a version of the scheme with the given format of the serializable
+// representation.
+func isVersion(version, format string) bool {
+	scheme, schemeErr := parseSchemeFormat(format)
+	if schemeErr != nil {
+		return false
+	}
+	_, parseErr := scheme.Parse(version)
+
+	return parseErr == nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+func TestVersion_LDFlags(t *testing.T) {
+	version := deriveTestVersion(t)
+
+	flags := version.LDFlags(glGit.BuildVars{
+		Branch:     "main.branch",
+		CommitHash: "main.commit",
+		Dirty:      "main.dirty",
+		Version:    "main.version",
+	})
+	assert.Equal(t,
+		"-X main.version="+version.String()+" -X main.commit="+version.CommitHash.String()+
+			" -X main.branch=master -X main.dirty=false",
+		flags,
+	)
+
+	flags = version.LDFlags(glGit.BuildVars{Version: "github.com/svengreb/golib/internal/build.version"})
+	assert.Equal(t, "-X github.com/svengreb/golib/internal/build.version="+version.String(), flags)
+
+	version.Branch = "feature branch"
+	assert.Equal(t, "-X 'main.branch=feature branch'", version.LDFlags(glGit.BuildVars{Branch: "main.branch"}))
+}
+
+func TestReadBuildVersion(t *testing.T) {
+	version := deriveTestVersion(t)
+
+	read, err := glGit.ReadBuildVersion(testDefaultVersion, glGit.BuildVars{
+		Branch:     version.Branch,
+		CommitHash: version.CommitHash.String(),
+		Dirty:      "true",
+		Version:    version.String(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, version.String(), read.String())
+	assert.Equal(t, version.Branch, read.Branch)
+	assert.Equal(t, version.HeadRef, read.HeadRef)
+	assert.Equal(t, version.CommitHash, read.CommitHash)
+	assert.True(t, read.Dirty)
+
+	_, err = glGit.ReadBuildVersion(testDefaultVersion, glGit.BuildVars{Dirty: "maybe", Version: "1.0.0"})
+	assert.True(t, errors.Is(err, glGit.ErrInvalidVersion))
+}
+
+func TestReadBuildVersion_BuildInfo(t *testing.T) {
+	// Test binaries are not stamped with a module version nor VCS information.
+	read, err := glGit.ReadBuildVersion(testDefaultVersion, glGit.BuildVars{})
+	assert.NoError(t, err)
+	assert.Equal(t, testDefaultVersion, read.String())
+
+	_, err = glGit.ReadBuildVersion("invalid", glGit.BuildVars{})
+	assert.True(t, errors.Is(err, glGit.ErrInvalidDefaultVersion))
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// CommitsBetween returns all commits of a Git repository that are reachable from the "to" revision, but not from the
+// "from" revision, ordered by committer time, newest first, like the "git log from..to" command.
+// The revisions can be any revision supported by the Git "rev-parse" command, like a branch, tag or commit hash.
+// All commits reachable from the "to" revision are returned when "from" is empty while "to" defaults to HEAD when
+// empty.
+func CommitsBetween(repositoryPath, from, to string) ([]*object.Commit, error) {
+	repo, repoOpenErr := openRepository(repositoryPath, NewOptions())
+	if repoOpenErr != nil {
+		return nil, repoOpenErr
+	}
+
+	var fromHash plumbing.Hash
+	if from != "" {
+		hash, resolveErr := repo.ResolveRevision(plumbing.Revision(from))
+		if resolveErr != nil {
+			return nil, newError(ErrRevisionNotFound, resolveErr, "failed to resolve revision %q", from)
+		}
+		fromHash = *hash
+	}
+	if to == "" {
+		to = plumbing.HEAD.String()
+	}
+	toHash, resolveErr := repo.ResolveRevision(plumbing.Revision(to))
+	if resolveErr != nil {
+		return nil, newError(ErrRevisionNotFound, resolveErr, "failed to resolve revision %q", to)
+	}
+
+	return commitsBetween(repo, fromHash, *toHash)
+}
+
+// commitsBetween returns all commits that are reachable from the commit with the given "to" hash, but not from the
+// commit with the given "from" hash, ordered by committer time, newest first.
+// All commits reachable from the "to" commit are returned when the "from" hash is the zero hash.
+func commitsBetween(repo *git.Repository, from, to plumbing.Hash) ([]*object.Commit, error) {
+	excluded := make(map[plumbing.Hash]bool)
+	if !from.IsZero() {
+		fromCommit, fromErr := repo.CommitObject(from)
+		if fromErr != nil {
+			return nil, fmt.Errorf("failed to get commit %s: %w", from, fromErr)
+		}
+		fromIterator := object.NewCommitPreorderIter(fromCommit, nil, nil)
+		defer fromIterator.Close()
+		if iterErr := fromIterator.ForEach(func(c *object.Commit) error {
+			excluded[c.Hash] = true
+			return nil
+		}); iterErr != nil {
+			return nil, fmt.Errorf("failed to iterate over commits of %s: %w", from, iterErr)
+		}
+	}
+
+	toCommit, toErr := repo.CommitObject(to)
+	if toErr != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", to, toErr)
+	}
+	var commits []*object.Commit
+	toIterator := object.NewCommitIterCTime(toCommit, excluded, nil)
+	defer toIterator.Close()
+	if iterErr := toIterator.ForEach(func(c *object.Commit) error {
+		commits = append(commits, c)
+		return nil
+	}); iterErr != nil {
+		return nil, fmt.Errorf("failed to iterate over commits of %s: %w", to, iterErr)
+	}
+
+	return commits, nil
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// MailmapFileName is the name of the mailmap file in the root directory of a repository.
+const MailmapFileName = ".mailmap"
+
+// Mailmap maps the names and email addresses of commit identities to canonical ones.
+// It supports all line forms of the Git mailmap format:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+//
+// Like Git, names and email addresses are matched case-insensitively, lines starting with a "#" are comments and
+// malformed lines are ignored.
+// See https://git-scm.com/docs/gitmailmap for more details.
+type Mailmap struct {
+	// identities are the mapped identities by the lowercase commit email address.
+	identities map[string]*mailmapIdentity
+}
+
+// mailmapIdentity is the canonical identity of a commit email address.
+type mailmapIdentity struct {
+	// email is the proper email address, or empty when it is not mapped.
+	email string
+
+	// name is the proper name, or empty when it is not mapped.
+	name string
+
+	// names are the canonical identities of the commit email address in combination with a commit name, by the
+	// lowercase commit name.
+	names map[string]*mailmapIdentity
+}
+
+// Contributor is a canonical identity together with the amount of commits authored by it.
+type Contributor struct {
+	// Commits is the amount of commits authored by the contributor.
+	Commits int
+
+	// Email is the canonical email address.
+	Email string
+
+	// Name is the canonical name.
+	Name string
+}
+
+// ParseMailmap parses the mailmap in the Git mailmap format from the given reader.
+func ParseMailmap(r io.Reader) (*Mailmap, error) {
+	mm := &Mailmap{identities: make(map[string]*mailmapIdentity)}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		mm.parseLine(scanner.Text())
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return nil, fmt.Errorf("failed to read mailmap: %w", scanErr)
+	}
+
+	return mm, nil
+}
+
+// ReadMailmap reads the mailmap file of a Git repository.
+// An empty mailmap is returned when the repository has no mailmap file.
+// The DetectDotGit and CeilingDirs options are used to find the repository while all other options are ignored.
+func ReadMailmap(repositoryPath string, opts ...Option) (*Mailmap, error) {
+	repo, repoOpenErr := openRepository(repositoryPath, NewOptions(opts...))
+	if repoOpenErr != nil {
+		return nil, repoOpenErr
+	}
+
+	return ReadMailmapFromRepository(repo)
+}
+
+// ReadMailmapFromRepository is like ReadMailmap but reads the mailmap file of the given already opened repository.
+// Like Git, the mailmap file is read from the root directory of the worktree or, for bare repositories, from the tree
+// of the commit HEAD is pointing to.
+func ReadMailmapFromRepository(repo *git.Repository) (*Mailmap, error) {
+	if repo == nil {
+		return nil, newError(ErrRepositoryNotFound, nil, "repository must not be nil")
+	}
+
+	wt, wtErr := repo.Worktree()
+	switch {
+	case errors.Is(wtErr, git.ErrIsBareRepository):
+		return readMailmapFromHead(repo)
+	case wtErr != nil:
+		return nil, fmt.Errorf("failed to get worktree: %w", wtErr)
+	}
+	f, openErr := wt.Filesystem.Open(MailmapFileName)
+	switch {
+	case os.IsNotExist(openErr):
+		return ParseMailmap(bytes.NewReader(nil))
+	case openErr != nil:
+		return nil, fmt.Errorf("failed to open %q: %w", filepath.Join(wt.Filesystem.Root(), MailmapFileName), openErr)
+	}
+	defer func() { _ = f.Close() }()
+
+	return ParseMailmap(f)
+}
+
+// readMailmapFromHead reads the mailmap file from the tree of the commit HEAD is pointing to.
+func readMailmapFromHead(repo *git.Repository) (*Mailmap, error) {
+	tree, treeErr := resolveHeadTree(repo)
+	if treeErr != nil {
+		return nil, treeErr
+	}
+	if tree == nil {
+		return ParseMailmap(bytes.NewReader(nil))
+	}
+	file, fileErr := tree.File(MailmapFileName)
+	switch {
+	case errors.Is(fileErr, object.ErrFileNotFound):
+		return ParseMailmap(bytes.NewReader(nil))
+	case fileErr != nil:
+		return nil, fmt.Errorf("failed to get %q from tree of HEAD: %w", MailmapFileName, fileErr)
+	}
+	r, readerErr := file.Reader()
+	if readerErr != nil {
+		return nil, fmt.Errorf("failed to read %q from tree of HEAD: %w", MailmapFileName, readerErr)
+	}
+	defer func() { _ = r.Close() }()
+
+	return ParseMailmap(r)
+}
+
+// Resolve returns the canonical name and email address of the given commit name and email address.
+// The mapping of a commit name in combination with the email address takes precedence over the mapping of the email
+// address alone, and any part that is not mapped is returned unchanged.
+func (mm *Mailmap) Resolve(name, email string) (string, string) {
+	if mm == nil {
+		return name, email
+	}
+	identity, ok := mm.identities[strings.ToLower(email)]
+	if !ok {
+		return name, email
+	}
+	if named, ok := identity.names[strings.ToLower(name)]; ok {
+		identity = named
+	}
+	if identity.name != "" {
+		name = identity.name
+	}
+	if identity.email != "" {
+		email = identity.email
+	}
+
+	return name, email
+}
+
+// ResolveSignature returns a copy of the given signature with the canonical name and email address.
+func (mm *Mailmap) ResolveSignature(sig object.Signature) object.Signature {
+	sig.Name, sig.Email = mm.Resolve(sig.Name, sig.Email)
+	return sig
+}
+
+// parseLine parses a single line in the Git mailmap format and adds its mapping.
+// Later lines take precedence over earlier lines for the same commit identity.
+func (mm *Mailmap) parseLine(line string) {
+	if strings.HasPrefix(line, "#") {
+		return
+	}
+	properName, properEmail, rest, ok := parseMailmapIdentity(line, false)
+	if !ok {
+		return
+	}
+	commitName, commitEmail, _, ok := parseMailmapIdentity(rest, true)
+	if !ok {
+		// The "Proper Name <commit@email>" form only has a single email address that is the commit email address.
+		commitName, commitEmail, properEmail = "", properEmail, ""
+	}
+
+	identity, exists := mm.identities[strings.ToLower(commitEmail)]
+	if !exists {
+		identity = &mailmapIdentity{}
+		mm.identities[strings.ToLower(commitEmail)] = identity
+	}
+	if commitName == "" {
+		if properName != "" {
+			identity.name = properName
+		}
+		if properEmail != "" {
+			identity.email = properEmail
+		}
+		return
+	}
+	if identity.names == nil {
+		identity.names = make(map[string]*mailmapIdentity)
+	}
+	identity.names[strings.ToLower(commitName)] = &mailmapIdentity{email: properEmail, name: properName}
+}
+
+// parseMailmapIdentity parses a name followed by an email address in angle brackets from the start of the given text
+// and returns them together with the remaining text.
+// Both the name and the remaining text can be empty while an empty email address is only allowed when explicitly
+// enabled.
+func parseMailmapIdentity(text string, allowEmptyEmail bool) (name, email, rest string, ok bool) {
+	left := strings.IndexByte(text, '<')
+	if left < 0 {
+		return "", "", "", false
+	}
+	right := strings.IndexByte(text[left+1:], '>')
+	if right < 0 || (right == 0 && !allowEmptyEmail) {
+		return "", "", "", false
+	}
+
+	return strings.TrimSpace(text[:left]), text[left+1 : left+1+right], text[left+1+right+1:], true
+}
+
+// Shortlog summarizes the commits of a Git repository matching the given query by their canonical author identities,
+// like the Git "shortlog --summary --numbered --email" command.
+// The mailmap of the query is used to resolve the canonical identities, or the mailmap file of the repository when
+// the mailmap of the query is nil. The Limit and Offset of the query are ignored so that all matching commits are
+// summarized.
+// The contributors are ordered by their amount of commits, most first, and by name and email address when equal.
+// The DetectDotGit and CeilingDirs options are used to find the repository while all other options are ignored.
+func Shortlog(repositoryPath string, query *LogQuery, opts ...Option) ([]*Contributor, error) {
+	repo, repoOpenErr := openRepository(repositoryPath, NewOptions(opts...))
+	if repoOpenErr != nil {
+		return nil, repoOpenErr
+	}
+
+	return ShortlogFromRepository(repo, query)
+}
+
+// ShortlogFromRepository is like Shortlog but summarizes the commits of the given already opened repository.
+func ShortlogFromRepository(repo *git.Repository, query *LogQuery) ([]*Contributor, error) {
+	if repo == nil {
+		return nil, newError(ErrRepositoryNotFound, nil, "repository must not be nil")
+	}
+	q := LogQuery{}
+	if query != nil {
+		q = *query
+	}
+	q.Limit, q.Offset = 0, 0
+	if q.Mailmap == nil {
+		mm, mmErr := ReadMailmapFromRepository(repo)
+		if mmErr != nil {
+			return nil, mmErr
+		}
+		q.Mailmap = mm
+	}
+
+	page, queryErr := QueryLogFromRepository(repo, &q)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	var contributors []*Contributor
+	byIdentity := make(map[string]*Contributor)
+	for _, commit := range page.Commits {
+		key := formatSignature(&commit.Author)
+		contributor, ok := byIdentity[key]
+		if !ok {
+			contributor = &Contributor{Email: commit.Author.Email, Name: commit.Author.Name}
+			byIdentity[key] = contributor
+			contributors = append(contributors, contributor)
+		}
+		contributor.Commits++
+	}
+	sort.Slice(contributors, func(i, j int) bool {
+		a, b := contributors[i], contributors[j]
+		switch {
+		case a.Commits != b.Commits:
+			return a.Commits > b.Commits
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		default:
+			return a.Email < b.Email
+		}
+	})
+
+	return contributors, nil
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+const testMailmap = `# Comment lines and malformed lines are ignored.
+Malformed <
+<> <empty@example.com>
+Arctic Ice <arctic@example.com>
+<frost@example.com> <frost@old.example.com>
+Snow Flake <snow@example.com> <snowflake@example.com> # Trailing text is ignored.
+Polar Bear <polar@example.com> Polar <bear@example.com>
+Polar Cub <cub@example.com> cub <bear@example.com>
+Later Line <ARCTIC@example.com>
+`
+
+func TestMailmap_Resolve(t *testing.T) {
+	mm, err := glGit.ParseMailmap(strings.NewReader(testMailmap))
+	if err != nil {
+		assert.FailNow(t, "failed to parse mailmap", "error: %v", err)
+	}
+
+	testCases := []struct {
+		email         string
+		expectedEmail string
+		expectedName  string
+		name          string
+	}{
+		// Proper Name <commit@email>, with a later line taking precedence.
+		{name: "arctic", email: "arctic@example.com", expectedName: "Later Line", expectedEmail: "arctic@example.com"},
+		// <proper@email> <commit@email>
+		{name: "Frost", email: "FROST@old.example.com", expectedName: "Frost", expectedEmail: "frost@example.com"},
+		// Proper Name <proper@email> <commit@email>
+		{name: "snow", email: "snowflake@example.com", expectedName: "Snow Flake", expectedEmail: "snow@example.com"},
+		// Proper Name <proper@email> Commit Name <commit@email>
+		{name: "POLAR", email: "bear@example.com", expectedName: "Polar Bear", expectedEmail: "polar@example.com"},
+		{name: "Cub", email: "bear@example.com", expectedName: "Polar Cub", expectedEmail: "cub@example.com"},
+		{name: "Other", email: "bear@example.com", expectedName: "Other", expectedEmail: "bear@example.com"},
+		{name: "Empty", email: "empty@example.com", expectedName: "Empty", expectedEmail: "empty@example.com"},
+		{name: "Unknown", email: "unknown@example.com", expectedName: "Unknown", expectedEmail: "unknown@example.com"},
+	}
+
+	for _, tc := range testCases {
+		name, email := mm.Resolve(tc.name, tc.email)
+		assert.Equal(t, tc.expectedName, name, "identity: %s <%s>", tc.name, tc.email)
+		assert.Equal(t, tc.expectedEmail, email, "identity: %s <%s>", tc.name, tc.email)
+	}
+
+	sig := mm.ResolveSignature(object.Signature{Name: "snow", Email: "snowflake@example.com"})
+	assert.Equal(t, "Snow Flake", sig.Name)
+	assert.Equal(t, "snow@example.com", sig.Email)
+
+	var nilMailmap *glGit.Mailmap
+	name, email := nilMailmap.Resolve("Name", "name@example.com")
+	assert.Equal(t, "Name", name)
+	assert.Equal(t, "name@example.com", email)
+}
+
+func TestShortlog(t *testing.T) {
+	tr := newTestRepository(t)
+	old := &object.Signature{Name: "sven", Email: "sven@old.example.com"}
+	arctic := &object.Signature{Name: "Arctic Ice", Email: "arctic@example.com"}
+	tr.commitFile(glGit.MailmapFileName, "Sven Greb <development@svengreb.de> <sven@old.example.com>\n", old)
+	first := tr.commitFile("a.go", "feat: add a", arctic)
+	tr.commitFile("b.go", "feat: add b", tr.signature())
+	tr.commitFile("c.go", "feat: add c", old)
+
+	contributors, err := glGit.Shortlog(tr.path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*glGit.Contributor{
+		{Name: "Sven Greb", Email: "development@svengreb.de", Commits: 3},
+		{Name: "Arctic Ice", Email: "arctic@example.com", Commits: 1},
+	}, contributors)
+
+	contributors, err = glGit.Shortlog(tr.path, &glGit.LogQuery{Range: first.String() + "..HEAD"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*glGit.Contributor{{Name: "Sven Greb", Email: "development@svengreb.de", Commits: 2}}, contributors)
+
+	// The pagination of the query is ignored so that all matching commits are summarized.
+	contributors, err = glGit.Shortlog(tr.path, &glGit.LogQuery{Limit: 1, Offset: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []*glGit.Contributor{
+		{Name: "Sven Greb", Email: "development@svengreb.de", Commits: 3},
+		{Name: "Arctic Ice", Email: "arctic@example.com", Commits: 1},
+	}, contributors)
+
+	// An explicit mailmap takes precedence over the mailmap file of the repository.
+	mm, err := glGit.ParseMailmap(strings.NewReader("Arctic <arctic@example.com>\n"))
+	if err != nil {
+		assert.FailNow(t, "failed to parse mailmap", "error: %v", err)
+	}
+	contributors, err = glGit.Shortlog(tr.path, &glGit.LogQuery{Mailmap: mm, Range: "HEAD~2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*glGit.Contributor{
+		{Name: "Arctic", Email: "arctic@example.com", Commits: 1},
+		{Name: "sven", Email: "sven@old.example.com", Commits: 1},
+	}, contributors)
+}
+
+func TestQueryLog_Mailmap(t *testing.T) {
+	tr := newTestRepository(t)
+	old := &object.Signature{Name: "sven", Email: "sven@old.example.com"}
+	first := tr.commitFile("a.go", "feat: add a", tr.signature())
+	head := tr.commitFile("b.go", "feat: add b", old)
+
+	mm, err := glGit.ReadMailmap(tr.path)
+	assert.NoError(t, err)
+	name, _ := mm.Resolve("sven", "sven@old.example.com")
+	assert.Equal(t, "sven", name)
+
+	tr.writeFile(glGit.MailmapFileName, "Sven Greb <development@svengreb.de> <sven@old.example.com>\n")
+	mm, err = glGit.ReadMailmap(tr.path)
+	assert.NoError(t, err)
+
+	page, err := glGit.QueryLog(tr.path, &glGit.LogQuery{Author: "^Sven Greb <development@", Mailmap: mm})
+	assert.NoError(t, err)
+	assert.Len(t, page.Commits, 2)
+	assert.Equal(t, head, page.Commits[0].Hash)
+	assert.Equal(t, "Sven Greb", page.Commits[0].Author.Name)
+	assert.Equal(t, "development@svengreb.de", page.Commits[0].Author.Email)
+
+	page, err = glGit.QueryLog(tr.path, &glGit.LogQuery{Author: "^Sven Greb <development@"})
+	assert.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{first}, logHashes(page))
+}
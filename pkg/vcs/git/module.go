@@ -0,0 +1,212 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/helper/chroot"
+	"github.com/go-git/go-billy/v5/helper/polyfill"
+	"github.com/go-git/go-billy/v5/osfs"
+
+	glFilepath "github.com/svengreb/golib/pkg/io/fs/filepath"
+)
+
+// goModFileName is the name of the Go module file.
+const goModFileName = "go.mod"
+
+// moduleTagPrefix derives the tag prefix of the Go module in the given directory relative to the given repository root
+// directory.
+// Like the Go toolchain, a major version subdirectory, e.g. "v2" of a module with the path "example.com/repo/mod/v2",
+// is not part of the prefix so the module is tagged as "mod/v2.0.0" instead of "mod/v2/v2.0.0".
+// The prefix is empty for a module in the repository root directory.
+//
+// See https://go.dev/ref/mod#vcs-version for more details.
+func moduleTagPrefix(repoRootDir, moduleDir string) (string, error) {
+	absRepoRootDir, absErr := filepath.Abs(repoRootDir)
+	if absErr != nil {
+		return "", fmt.Errorf("failed to get absolute path of repository root directory %q: %w", repoRootDir, absErr)
+	}
+	absModuleDir, absErr := filepath.Abs(moduleDir)
+	if absErr != nil {
+		return "", fmt.Errorf("failed to get absolute path of module directory %q: %w", moduleDir, absErr)
+	}
+
+	isSubDir, subDirErr := glFilepath.IsSubDir(absRepoRootDir, absModuleDir, true)
+	if subDirErr != nil {
+		return "", fmt.Errorf("failed to check if module directory %q is within repository root directory %q: %w",
+			moduleDir, repoRootDir, subDirErr)
+	}
+	if !isSubDir {
+		return "", fmt.Errorf("module directory %q is not within repository root directory %q", moduleDir, repoRootDir)
+	}
+
+	modPath, modPathErr := readModulePath(filepath.Join(absModuleDir, goModFileName))
+	if modPathErr != nil {
+		return "", modPathErr
+	}
+
+	rel, relErr := filepath.Rel(absRepoRootDir, absModuleDir)
+	if relErr != nil {
+		return "", fmt.Errorf("failed to get module directory %q relative to repository root directory %q: %w",
+			moduleDir, repoRootDir, relErr)
+	}
+
+	return relTagPrefix(filepath.ToSlash(rel), modPath), nil
+}
+
+// worktreeModuleTagPrefix is like moduleTagPrefix but reads the Go module file from the given worktree filesystem,
+// e.g. an in-memory filesystem, where the module directory is a path relative to its root.
+func worktreeModuleTagPrefix(wtFS billy.Filesystem, moduleDir string) (string, error) {
+	rel, relErr := worktreeModuleDir(wtFS, moduleDir)
+	if relErr != nil {
+		return "", relErr
+	}
+	modPath, modPathErr := readWorktreeModulePath(wtFS, rel)
+	if modPathErr != nil {
+		return "", modPathErr
+	}
+
+	return relTagPrefix(rel, modPath), nil
+}
+
+// worktreeModuleDir returns the given module directory as slash-separated path relative to the root of the given
+// worktree filesystem, or "." for the root itself.
+// When the worktree filesystem is backed by the filesystem of the operating system, the module directory is resolved
+// like a path of the operating system, otherwise it must be relative to the root of the worktree filesystem.
+func worktreeModuleDir(wtFS billy.Filesystem, moduleDir string) (string, error) {
+	if isOSFilesystem(wtFS) {
+		absRootDir, absErr := filepath.Abs(wtFS.Root())
+		if absErr != nil {
+			return "", fmt.Errorf("failed to get absolute path of worktree root directory %q: %w", wtFS.Root(), absErr)
+		}
+		absModuleDir, absErr := filepath.Abs(moduleDir)
+		if absErr != nil {
+			return "", fmt.Errorf("failed to get absolute path of module directory %q: %w", moduleDir, absErr)
+		}
+		rel, relErr := filepath.Rel(absRootDir, absModuleDir)
+		if relErr != nil {
+			return "", fmt.Errorf("failed to get module directory %q relative to worktree root directory %q: %w",
+				moduleDir, wtFS.Root(), relErr)
+		}
+		moduleDir = rel
+	}
+
+	return cleanModuleDir(moduleDir)
+}
+
+// cleanModuleDir returns the given module directory relative to the root directory of the repository as clean
+// slash-separated path, or "." for the root directory itself.
+func cleanModuleDir(moduleDir string) (string, error) {
+	rel := strings.TrimPrefix(path.Clean(filepath.ToSlash(moduleDir)), "/")
+	switch {
+	case rel == "":
+		rel = "."
+	case rel == ".." || strings.HasPrefix(rel, "../"):
+		return "", fmt.Errorf("module directory %q is not within the repository", moduleDir)
+	}
+
+	return rel, nil
+}
+
+// readWorktreeModulePath reads the module path from the Go module file in the given slash-separated directory relative
+// to the root of the given worktree filesystem.
+func readWorktreeModulePath(wtFS billy.Filesystem, rel string) (string, error) {
+	goModFile := path.Join(rel, goModFileName)
+	file, openErr := wtFS.Open(goModFile)
+	if openErr != nil {
+		return "", fmt.Errorf("failed to open Go module file %q: %w", goModFile, openErr)
+	}
+	defer func() { _ = file.Close() }()
+	data, readErr := io.ReadAll(file)
+	if readErr != nil {
+		return "", fmt.Errorf("failed to read Go module file %q: %w", goModFile, readErr)
+	}
+
+	return parseModulePath(data, goModFile)
+}
+
+// relTagPrefix returns the tag prefix of the module with the given path in the given slash-separated directory relative
+// to the repository root directory without a major version subdirectory.
+func relTagPrefix(rel, modPath string) string {
+	if rel == "." {
+		return ""
+	}
+	if isMajorVersionSuffix(path.Base(rel)) && path.Base(modPath) == path.Base(rel) {
+		rel = path.Dir(rel)
+		if rel == "." {
+			return ""
+		}
+	}
+
+	return rel + "/"
+}
+
+// isOSFilesystem checks if the given filesystem is backed by the filesystem of the operating system.
+func isOSFilesystem(fs billy.Filesystem) bool {
+	chrootFS, ok := fs.(*chroot.ChrootHelper)
+	if !ok {
+		return false
+	}
+	underlying := chrootFS.Underlying()
+	if polyfillFS, isPolyfill := underlying.(*polyfill.Polyfill); isPolyfill {
+		underlying = polyfillFS.Underlying()
+	}
+	_, ok = underlying.(*osfs.OS)
+
+	return ok
+}
+
+// readModulePath reads the module path from the "module" directive of the given Go module file.
+func readModulePath(goModFile string) (string, error) {
+	data, readErr := os.ReadFile(goModFile)
+	if readErr != nil {
+		return "", fmt.Errorf("failed to read Go module file %q: %w", goModFile, readErr)
+	}
+
+	return parseModulePath(data, goModFile)
+}
+
+// parseModulePath parses the module path from the "module" directive of the given Go module file data.
+func parseModulePath(data []byte, goModFile string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		rest := strings.TrimPrefix(line, "module")
+		if rest == line || rest == "" || !strings.ContainsAny(rest[:1], " \t\"") {
+			continue
+		}
+		modPath := strings.TrimSpace(rest)
+		if idx := strings.Index(modPath, "//"); idx >= 0 {
+			modPath = strings.TrimSpace(modPath[:idx])
+		}
+		if unquoted, unquoteErr := strconv.Unquote(modPath); unquoteErr == nil {
+			modPath = unquoted
+		}
+		if modPath != "" {
+			return modPath, nil
+		}
+	}
+
+	return "", fmt.Errorf("no module path found in Go module file %q", goModFile)
+}
+
+// isMajorVersionSuffix checks if the given path element is a major version suffix like "v2".
+func isMajorVersionSuffix(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' || elem[1] == '0' {
+		return false
+	}
+	major, err := strconv.Atoi(elem[1:])
+
+	return err == nil && major >= 2
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/svengreb/golib/pkg/vcs/git/conventional"
+)
+
+// ConventionalCommit is a commit whose message follows the "Conventional Commits" specification.
+type ConventionalCommit struct {
+	// Bump is the version bump implied by the commit.
+	Bump conventional.Bump
+
+	// Commit is the commit object.
+	Commit *object.Commit
+
+	// Message is the parsed commit message.
+	Message *conventional.Commit
+}
+
+// NextVersion stores the recommended next version derived from the commits since the latest version tag.
+type NextVersion struct {
+	// Bump is the most significant version bump implied by all commits since the latest version tag.
+	Bump conventional.Bump
+
+	// Commits are all commits since the latest version tag whose message follows the "Conventional Commits"
+	// specification, ordered by committer time, newest first.
+	// Commits with messages that do not follow the specification, like merge commits, are not included.
+	Commits []*ConventionalCommit
+
+	// Current is the version derived from the repository.
+	Current *Version
+
+	// Next is the recommended next version.
+	// It is the same as the version of the latest version tag, or the default version if there is none, when no commit
+	// implies a version bump.
+	Next *semver.Version
+}
+
+// String returns the recommended next version in the format of the version scheme it has been derived with.
+func (nv *NextVersion) String() string {
+	if nv.Current == nil || nv.Current.scheme == nil {
+		return nv.Next.String()
+	}
+
+	return nv.Current.scheme.Format(nv.Next)
+}
+
+// CommitsFor returns all commits that imply the given version bump.
+func (nv *NextVersion) CommitsFor(bump conventional.Bump) []*ConventionalCommit {
+	var commits []*ConventionalCommit
+	for _, c := range nv.Commits {
+		if c.Bump == bump {
+			commits = append(commits, c)
+		}
+	}
+
+	return commits
+}
+
+// DeriveNextVersion derives the recommended next version from the commits between the latest version tag and HEAD of a
+// Git repository whose messages follow the "Conventional Commits" specification.
+// The version of the latest version tag, or the given default version if no tag is found, is bumped by the most
+// significant version bump implied by the commits. Commits that introduce breaking changes imply a major, new features
+// a minor and bug fixes as well as performance improvements a patch version bump. The mapping of commit types to
+// version bumps can be customized through the TypeBumps option while all other options are used to derive the version
+// like DeriveVersion.
+// Note that the next version is computed by the version scheme set through the Scheme option, so the version bump is,
+// for example, ignored by the CalVerScheme that uses the current date instead.
+// An error wrapping ErrVersionConstraint is returned when the next version does not satisfy the version constraint of
+// the branch set through the BranchRules option, e.g. when a new feature is committed to a maintenance branch.
+//
+// See https://www.conventionalcommits.org for more details about "Conventional Commits".
+func DeriveNextVersion(defaultVersion, repositoryPath string, opts ...Option) (*NextVersion, error) {
+	opt := NewOptions(opts...)
+	if validateErr := validateDerivation(defaultVersion, opt); validateErr != nil {
+		return nil, validateErr
+	}
+
+	repo, repoOpenErr := openRepository(repositoryPath, opt)
+	if repoOpenErr != nil {
+		return nil, repoOpenErr
+	}
+
+	return deriveNextVersion(repo, defaultVersion, opt)
+}
+
+// deriveNextVersion derives the recommended next version from the given repository.
+// The default version and options must have been validated before.
+func deriveNextVersion(repo *git.Repository, defaultVersion string, opt *Options) (*NextVersion, error) {
+	current, versionErr := deriveVersion(context.Background(), repo, defaultVersion, opt)
+	if versionErr != nil {
+		return nil, versionErr
+	}
+
+	base := current.tagVersion
+	var since plumbing.Hash
+	if current.LatestVersionTag != nil {
+		// The distance is only 0 when HEAD is pointing to the tagged commit.
+		if current.CommitsAhead == 0 {
+			return &NextVersion{Current: current, Next: base}, nil
+		}
+		if since, versionErr = peelTag(repo, current.LatestVersionTag); versionErr != nil {
+			return nil, versionErr
+		}
+	} else {
+		var parseErr error
+		if base, parseErr = opt.versionScheme().Parse(defaultVersion); parseErr != nil {
+			return nil, newError(ErrInvalidDefaultVersion, parseErr, "failed to parse default version %q", defaultVersion)
+		}
+	}
+
+	commits, commitsErr := commitsBetween(repo, since, current.CommitHash)
+	if commitsErr != nil {
+		return nil, commitsErr
+	}
+
+	next := &NextVersion{Current: current, Next: base}
+	for _, c := range commits {
+		msg, parseErr := conventional.Parse(c.Message)
+		if parseErr != nil {
+			continue
+		}
+		cc := &ConventionalCommit{Bump: msg.Bump(opt.TypeBumps), Commit: c, Message: msg}
+		next.Commits = append(next.Commits, cc)
+		if cc.Bump > next.Bump {
+			next.Bump = cc.Bump
+		}
+	}
+
+	if next.Bump == conventional.BumpNone {
+		return next, nil
+	}
+	bumped, bumpErr := opt.versionScheme().Next(base, next.Bump, time.Now())
+	if bumpErr != nil {
+		return nil, bumpErr
+	}
+	constraint := resolveBranchConstraint(current.Branch, opt)
+	if constraintErr := checkBranchConstraint(constraint, bumped, current.Branch); constraintErr != nil {
+		return nil, constraintErr
+	}
+	next.Next = bumped
+
+	return next, nil
+}
+
+// peelTag returns the hash of the commit the given tag reference is pointing to.
+func peelTag(repo *git.Repository, ref *plumbing.Reference) (plumbing.Hash, error) {
+	tagObject, tagObjectErr := repo.TagObject(ref.Hash())
+	switch {
+	case errors.Is(tagObjectErr, plumbing.ErrObjectNotFound):
+		// Lightweight tags are pointing directly to the commit.
+		return ref.Hash(), nil
+	case tagObjectErr != nil:
+		return plumbing.ZeroHash, fmt.Errorf("failed to get tag object of tag %s: %w", ref.Name().Short(), tagObjectErr)
+	}
+	commit, commitErr := tagObject.Commit()
+	if commitErr != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get commit of tag %s: %w", ref.Name().Short(), commitErr)
+	}
+
+	return commit.Hash, nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+	"github.com/svengreb/golib/pkg/vcs/git/conventional"
+)
+
+func TestDeriveNextVersion(t *testing.T) {
+	tr := newTestRepository(t)
+	first := tr.commit("feat: initial feature")
+	tr.tag("v1.2.3", first, "v1.2.3")
+
+	next, err := glGit.DeriveNextVersion(testDefaultVersion, tr.path)
+	assert.NoError(t, err)
+	assert.Equal(t, conventional.BumpNone, next.Bump)
+	assert.Equal(t, "1.2.3", next.Next.String())
+	assert.Empty(t, next.Commits)
+
+	tr.commit("docs: update documentation")
+	fix := tr.commit("fix(vcs/git): fix bug")
+	tr.commit("Update something without conventional message")
+
+	next, err = glGit.DeriveNextVersion(testDefaultVersion, tr.path)
+	assert.NoError(t, err)
+	assert.Equal(t, conventional.BumpPatch, next.Bump)
+	assert.Equal(t, "1.2.4", next.Next.String())
+	assert.Len(t, next.Commits, 2)
+	if assert.Len(t, next.CommitsFor(conventional.BumpPatch), 1) {
+		assert.Equal(t, fix, next.CommitsFor(conventional.BumpPatch)[0].Commit.Hash)
+	}
+
+	feat := tr.commit("feat: add feature")
+
+	next, err = glGit.DeriveNextVersion(testDefaultVersion, tr.path)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0", next.Next.String())
+	if assert.Len(t, next.CommitsFor(conventional.BumpMinor), 1) {
+		assert.Equal(t, feat, next.CommitsFor(conventional.BumpMinor)[0].Commit.Hash)
+	}
+
+	tr.commit("refactor!: rename API")
+
+	next, err = glGit.DeriveNextVersion(testDefaultVersion, tr.path)
+	assert.NoError(t, err)
+	assert.Equal(t, conventional.BumpMajor, next.Bump)
+	assert.Equal(t, "2.0.0", next.Next.String())
+
+	next, err = glGit.DeriveNextVersion(testDefaultVersion, tr.path,
+		glGit.WithTypeBumps(map[string]conventional.Bump{"docs": conventional.BumpPatch}), glGit.WithMatchPatterns("none"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.0", next.Next.String())
+	assert.Len(t, next.Commits, 5)
+}
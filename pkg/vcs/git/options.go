@@ -0,0 +1,346 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"github.com/svengreb/golib/pkg/vcs/git/conventional"
+)
+
+const (
+	// DefaultDirtyMark is the default mark that is appended as build metadata when the worktree has uncommitted changes.
+	// The value is the same like the default value of Git without the leading hyphen.
+	DefaultDirtyMark = "dirty"
+
+	// DefaultHashAbbrevLength is the default amount of digits the commit hash is shortened to when appended as build
+	// metadata.
+	DefaultHashAbbrevLength = 8
+
+	// MaxHashAbbrevLength is the maximum amount of digits of a commit hash.
+	MaxHashAbbrevLength = 40
+
+	// MinHashAbbrevLength is the minimum amount of digits a commit hash can be shortened to.
+	// The value is the same like the minimum value of Git.
+	MinHashAbbrevLength = 4
+)
+
+// TraversalMode is the mode how the commit history is traversed to search for tag candidates and to calculate the
+// distance between a tag and HEAD.
+type TraversalMode int
+
+const (
+	// TraversalCommitterTime traverses all ancestors ordered by committer time and uses the position of the tagged commit
+	// in this order as distance.
+	// Note that this also counts commits of merged branches that are not reachable from the tag and can select a tag that
+	// is only reachable through a merged branch.
+	TraversalCommitterTime TraversalMode = iota
+
+	// TraversalFirstParent only follows the first parent of merge commits, so only tags of the mainline history are
+	// considered and the distance is the amount of commits between the tag and HEAD in the mainline history.
+	// This is the equivalent of the "--first-parent" flag of the Git "describe" command.
+	TraversalFirstParent
+
+	// TraversalGraph traverses all ancestors and uses the amount of commits that are reachable from HEAD, but not from
+	// the tag, as distance, which is the same as the Git "describe" command calculates it.
+	TraversalGraph
+)
+
+// Option is a version derivation option.
+type Option func(*Options)
+
+// Options are version derivation options.
+// The available options are modelled after the flags of the Git "describe" command so that the output of an existing
+// "git describe" based workflow can be reproduced.
+//
+// See https://git-scm.com/docs/git-describe for more details.
+type Options struct {
+	// Always indicates whether the shortened commit hash should be appended as build metadata to the default version
+	// when no suitable tag has been found.
+	// This is the equivalent of the "--always" flag of the Git "describe" command.
+	Always bool
+
+	// Branch is the short name of the branch that is used instead when HEAD is detached, like in most CI checkouts, so
+	// that the BranchRules and ChannelRules options are applied. The name can be read from the environment variables
+	// of common CI systems through the CIBranch function.
+	Branch string
+
+	// BranchRules map branches to version constraints where the first rule that matches the branch HEAD is pointing to
+	// is used.
+	// Only tags whose version satisfies the constraint are considered and an error wrapping ErrVersionConstraint is
+	// returned when the derived version does not satisfy it, e.g. when no such tag is found and the default version is
+	// used instead. Note that no rule is used when HEAD is detached and the Branch option is not set.
+	BranchRules []BranchRule
+
+	// Candidates is the maximum amount of suitable tag candidates to consider.
+	// A value of 0 only matches tags that are pointing exactly to the commit HEAD is pointing to.
+	// Note that values greater than 63 are clamped when using the TraversalFirstParent or TraversalGraph mode while the
+	// TraversalCommitterTime mode always stops at the first candidate because it is the nearest one.
+	// This is the equivalent of the "--candidates" flag of the Git "describe" command.
+	Candidates int
+
+	// CeilingDirs are directories the search for the repository root directory does not continue into when the
+	// DetectDotGit option is enabled.
+	// This is the equivalent of the "GIT_CEILING_DIRECTORIES" environment variable of Git.
+	CeilingDirs []string
+
+	// Channel is the default prerelease channel, like "alpha", "rc" or "dev", that is used when HEAD is detached or no
+	// rule of ChannelRules matches the branch HEAD is pointing to.
+	// When a channel is used and HEAD is not pointing exactly to the latest version tag, an ordered prerelease version of
+	// the next version is derived instead of appending the build metadata to the version of the tag, e.g.
+	// "1.2.4-dev.3+d1a0e8f3" for the third commit after the tag "v1.2.3". The shortened commit hash is appended as build
+	// metadata unless suppressed through HashAbbrevLength.
+	// An empty channel disables prerelease versions.
+	Channel string
+
+	// ChannelRules map branches to prerelease channels where the first rule that matches the branch HEAD is pointing to
+	// is used.
+	ChannelRules []ChannelRule
+
+	// DetectDotGit indicates whether the root directory of the repository should be searched upwards from the given
+	// repository path so that the path can also be any subdirectory of a repository.
+	DetectDotGit bool
+
+	// DirtyDetection indicates whether the worktree should be checked for uncommitted changes.
+	DirtyDetection bool
+
+	// DirtyMark is the mark that is appended as build metadata when the worktree has uncommitted changes.
+	// An empty mark only detects uncommitted changes without changing the version.
+	// This is the equivalent of the "--dirty" flag of the Git "describe" command.
+	DirtyMark string
+
+	// DirtyUntracked indicates whether untracked files should also be considered as uncommitted changes.
+	// Note that ignored files are never taken into account.
+	DirtyUntracked bool
+
+	// ExcludePatterns are glob patterns of tag names that should not be considered.
+	// The patterns are matched against the short tag name using the rules of the "path.Match" function.
+	// This is the equivalent of the "--exclude" flag of the Git "describe" command.
+	ExcludePatterns []string
+
+	// HashAbbrevLength is the amount of digits the commit hash is shortened to when appended as build metadata.
+	// A value of 0 suppresses the build metadata so that only the version of the tag is used.
+	// This is the equivalent of the "--abbrev" flag of the Git "describe" command.
+	HashAbbrevLength int
+
+	// Long indicates whether the build metadata should always be appended, even when HEAD is pointing exactly to a tag.
+	// This is the equivalent of the "--long" flag of the Git "describe" command.
+	Long bool
+
+	// ModuleDir is the path of a directory that contains a Go module file from which the tag prefix is derived.
+	// The prefix is the path of the directory relative to the root directory of the repository, without a major version
+	// subdirectory, like it is used by the Go toolchain to tag modules in multi-module repositories, e.g. "path/to/mod/"
+	// for tags like "path/to/mod/v1.2.3".
+	// Note that this takes precedence over TagPrefix.
+	//
+	// See https://go.dev/ref/mod#vcs-version for more details.
+	ModuleDir string
+
+	// MatchPatterns are glob patterns of tag names that should be considered while all non-matching tags are ignored.
+	// The patterns are matched against the short tag name using the rules of the "path.Match" function.
+	// This is the equivalent of the "--match" flag of the Git "describe" command.
+	MatchPatterns []string
+
+	// Scheme is the version scheme that defines which tag names, without the tag prefix, are versions and how the next
+	// version is computed.
+	// The SemVerScheme is used when nil.
+	Scheme Scheme
+
+	// TagPrefix is the prefix of tag names that is stripped before the remaining name is parsed as version.
+	// Tags without the prefix are not considered.
+	TagPrefix string
+
+	// TypeBumps maps the types of commits whose message follows the "Conventional Commits" specification to the version
+	// bump they are implying when deriving the next version.
+	// The conventional.DefaultTypeBumps are used when nil.
+	TypeBumps map[string]conventional.Bump
+
+	// TraversalMode is the mode how the commit history is traversed.
+	TraversalMode TraversalMode
+
+	// Tags indicates whether lightweight tags should be considered in addition to annotated tags.
+	// This is the equivalent of the "--tags" flag of the Git "describe" command.
+	Tags bool
+
+	// VerifyKeyRing is an armored OpenPGP key ring to verify the signatures of tags against.
+	// When set, only annotated tags with a signature that can be verified by one of the keys are considered while all
+	// lightweight, unsigned and tags with invalid signatures are ignored, even when the Tags option is enabled.
+	VerifyKeyRing string
+}
+
+// NewOptions creates new version derivation options.
+func NewOptions(opts ...Option) *Options {
+	opt := &Options{
+		Candidates:       MaxSuitableTagCandidates,
+		HashAbbrevLength: DefaultHashAbbrevLength,
+	}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	return opt
+}
+
+// WithAlways indicates whether the shortened commit hash should be appended as build metadata to the default version
+// when no suitable tag has been found.
+func WithAlways(always bool) Option {
+	return func(o *Options) {
+		o.Always = always
+	}
+}
+
+// WithBranch sets the short name of the branch that is used instead when HEAD is detached.
+func WithBranch(branch string) Option {
+	return func(o *Options) {
+		o.Branch = branch
+	}
+}
+
+// WithBranchRules adds rules that map branches to version constraints.
+func WithBranchRules(rules ...BranchRule) Option {
+	return func(o *Options) {
+		o.BranchRules = append(o.BranchRules, rules...)
+	}
+}
+
+// WithCandidates sets the maximum amount of suitable tag candidates to consider.
+// A value of 0 only matches tags that are pointing exactly to the commit HEAD is pointing to while any other value is
+// ignored in the TraversalCommitterTime mode because it always stops at the first candidate, which is the nearest one.
+func WithCandidates(candidates int) Option {
+	return func(o *Options) {
+		o.Candidates = candidates
+	}
+}
+
+// WithCeilingDirs adds directories the search for the repository root directory does not continue into.
+func WithCeilingDirs(dirs ...string) Option {
+	return func(o *Options) {
+		o.CeilingDirs = append(o.CeilingDirs, dirs...)
+	}
+}
+
+// WithChannel sets the default prerelease channel that is used when HEAD is detached or no channel rule matches.
+func WithChannel(channel string) Option {
+	return func(o *Options) {
+		o.Channel = channel
+	}
+}
+
+// WithChannelRules adds rules that map branches to prerelease channels.
+func WithChannelRules(rules ...ChannelRule) Option {
+	return func(o *Options) {
+		o.ChannelRules = append(o.ChannelRules, rules...)
+	}
+}
+
+// WithDetectDotGit indicates whether the root directory of the repository should be searched upwards from the given
+// repository path.
+func WithDetectDotGit(detect bool) Option {
+	return func(o *Options) {
+		o.DetectDotGit = detect
+	}
+}
+
+// WithDirtyDetection indicates whether the worktree should be checked for uncommitted changes.
+func WithDirtyDetection(dirtyDetection bool) Option {
+	return func(o *Options) {
+		o.DirtyDetection = dirtyDetection
+	}
+}
+
+// WithDirtyMark sets the mark that is appended as build metadata when the worktree has uncommitted changes.
+// Note that this also enables the detection of uncommitted changes.
+func WithDirtyMark(mark string) Option {
+	return func(o *Options) {
+		o.DirtyDetection = true
+		o.DirtyMark = mark
+	}
+}
+
+// WithDirtyUntracked indicates whether untracked files should also be considered as uncommitted changes.
+func WithDirtyUntracked(untracked bool) Option {
+	return func(o *Options) {
+		o.DirtyUntracked = untracked
+	}
+}
+
+// WithExcludePatterns adds glob patterns of tag names that should not be considered.
+func WithExcludePatterns(patterns ...string) Option {
+	return func(o *Options) {
+		o.ExcludePatterns = append(o.ExcludePatterns, patterns...)
+	}
+}
+
+// WithHashAbbrevLength sets the amount of digits the commit hash is shortened to when appended as build metadata.
+// A value of 0 suppresses the build metadata so that only the version of the tag is used.
+func WithHashAbbrevLength(length int) Option {
+	return func(o *Options) {
+		o.HashAbbrevLength = length
+	}
+}
+
+// WithLong indicates whether the build metadata should always be appended, even when HEAD is pointing exactly to a
+// tag.
+func WithLong(long bool) Option {
+	return func(o *Options) {
+		o.Long = long
+	}
+}
+
+// WithMatchPatterns adds glob patterns of tag names that should be considered while all non-matching tags are ignored.
+func WithMatchPatterns(patterns ...string) Option {
+	return func(o *Options) {
+		o.MatchPatterns = append(o.MatchPatterns, patterns...)
+	}
+}
+
+// WithModuleDir sets the path of a directory that contains a Go module file from which the tag prefix is derived.
+// Note that this takes precedence over the prefix set through WithTagPrefix.
+func WithModuleDir(dir string) Option {
+	return func(o *Options) {
+		o.ModuleDir = dir
+	}
+}
+
+// WithScheme sets the version scheme that defines which tag names are versions and how the next version is computed.
+func WithScheme(scheme Scheme) Option {
+	return func(o *Options) {
+		o.Scheme = scheme
+	}
+}
+
+// WithTagPrefix sets the prefix of tag names that is stripped before the remaining name is parsed as version.
+func WithTagPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.TagPrefix = prefix
+	}
+}
+
+// WithTags indicates whether lightweight tags should be considered in addition to annotated tags.
+func WithTags(tags bool) Option {
+	return func(o *Options) {
+		o.Tags = tags
+	}
+}
+
+// WithVerifyKeyRing sets the armored OpenPGP key ring to verify the signatures of tags against so that only tags with a
+// valid signature are considered.
+func WithVerifyKeyRing(armoredKeyRing string) Option {
+	return func(o *Options) {
+		o.VerifyKeyRing = armoredKeyRing
+	}
+}
+
+// WithTypeBumps sets the mapping of "Conventional Commits" types to the version bump they are implying when deriving the
+// next version.
+func WithTypeBumps(typeBumps map[string]conventional.Bump) Option {
+	return func(o *Options) {
+		o.TypeBumps = typeBumps
+	}
+}
+
+// WithTraversalMode sets the mode how the commit history is traversed.
+func WithTraversalMode(mode TraversalMode) Option {
+	return func(o *Options) {
+		o.TraversalMode = mode
+	}
+}
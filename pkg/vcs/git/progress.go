@@ -0,0 +1,92 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// CanceledError is the error returned when a version derivation has been canceled through its context before it has
+// been completed.
+// It reports how far the traversal of the tags and the commit history got before it has been canceled.
+type CanceledError struct {
+	// Commits is the amount of commits that have been visited.
+	Commits int
+
+	// Err is the error of the context, either context.Canceled or context.DeadlineExceeded.
+	Err error
+
+	// LastCommit is the hash of the last visited commit, or the zero hash if no commit has been visited.
+	LastCommit plumbing.Hash
+
+	// Tags is the amount of tags that have been scanned for version tag candidates.
+	Tags int
+}
+
+func (e *CanceledError) Error() string {
+	if e.LastCommit.IsZero() {
+		return fmt.Sprintf("version derivation canceled after scanning %d tags and %d commits: %v", e.Tags, e.Commits, e.Err)
+	}
+
+	return fmt.Sprintf("version derivation canceled after scanning %d tags and %d commits, last commit %s: %v",
+		e.Tags, e.Commits, e.LastCommit, e.Err)
+}
+
+// Unwrap returns the underlying error of the context.
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// progress tracks how far a version derivation got and checks for the cancellation of its context.
+type progress struct {
+	commits    int
+	ctx        context.Context
+	lastCommit plumbing.Hash
+	tags       int
+}
+
+// newProgress creates a new progress for the given context.
+func newProgress(ctx context.Context) *progress {
+	return &progress{ctx: ctx}
+}
+
+// check returns a CanceledError if the context has been canceled.
+func (p *progress) check() error {
+	if ctxErr := p.ctx.Err(); ctxErr != nil {
+		return &CanceledError{Commits: p.commits, Err: ctxErr, LastCommit: p.lastCommit, Tags: p.tags}
+	}
+
+	return nil
+}
+
+// commit records the visit of the commit with the given hash and checks for the cancellation of the context.
+func (p *progress) commit(hash plumbing.Hash) error {
+	if err := p.check(); err != nil {
+		return err
+	}
+	p.commits++
+	p.lastCommit = hash
+
+	return nil
+}
+
+// tag records the scan of a tag and checks for the cancellation of the context.
+func (p *progress) tag() error {
+	if err := p.check(); err != nil {
+		return err
+	}
+	p.tags++
+
+	return nil
+}
+
+// isCanceled checks if the given error is a CanceledError.
+func isCanceled(err error) bool {
+	var canceledErr *CanceledError
+	return errors.As(err, &canceledErr)
+}
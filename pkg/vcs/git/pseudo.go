@@ -0,0 +1,315 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	// PseudoVersionHashLength is the amount of digits the commit hash is shortened to in a pseudo-version.
+	PseudoVersionHashLength = 12
+
+	// PseudoVersionTimeFormat is the format of the UTC commit time in a pseudo-version.
+	PseudoVersionTimeFormat = "20060102150405"
+
+	// incompatibleSuffix is the build metadata suffix of versions with a major version of 2 or higher of modules that
+	// have no Go module file.
+	incompatibleSuffix = "+incompatible"
+)
+
+// PseudoVersion computes the Go module pseudo-version of the given revision of a Git repository, exactly like the Go
+// toolchain would assign it, e.g. "v0.0.0-20201121120000-abcdef123456" when no version tag is reachable from the
+// revision or "v1.2.4-0.20201121120000-abcdef123456" when "v1.2.3" is the highest reachable version tag.
+// The revision can be any revision supported by the Git "rev-parse" command, like a branch, tag or commit hash, and
+// defaults to HEAD when empty. When the revision itself is tagged with a version, this version is returned instead
+// like the Go toolchain resolves it.
+//
+// Like the Go toolchain, only canonical SemVer tags with a leading "v", like "v1.2.3" or "v1.2.3-pre", are considered
+// whose major version is compatible with the module path. The module path is read from the Go module file at the
+// revision in the directory set through the ModuleDir option, which is relative to the root directory of the
+// repository when it is bare, or in the root directory of the repository. The TagPrefix and ModuleDir options can be used to compute the pseudo-version of a module
+// in a multi-module repository and the DetectDotGit and CeilingDirs options to find the repository while all other
+// options are ignored.
+//
+// See https://go.dev/ref/mod#pseudo-versions for more details.
+func PseudoVersion(repositoryPath, revision string, opts ...Option) (string, error) {
+	opt := NewOptions(opts...)
+
+	repo, repoOpenErr := openRepository(repositoryPath, opt)
+	if repoOpenErr != nil {
+		return "", repoOpenErr
+	}
+
+	return pseudoVersion(repo, revision, opt)
+}
+
+// PseudoVersionFromRepository is like PseudoVersion but computes the pseudo-version of the given revision of the given
+// already opened repository, e.g. an in-memory repository.
+func PseudoVersionFromRepository(repo *git.Repository, revision string, opts ...Option) (string, error) {
+	if repo == nil {
+		return "", newError(ErrRepositoryNotFound, nil, "repository must not be nil")
+	}
+
+	return pseudoVersion(repo, revision, NewOptions(opts...))
+}
+
+// pseudoVersion computes the Go module pseudo-version of the given revision of the given repository.
+func pseudoVersion(repo *git.Repository, revision string, opt *Options) (string, error) {
+	if revision == "" {
+		revision = plumbing.HEAD.String()
+	}
+	hash, resolveErr := repo.ResolveRevision(plumbing.Revision(revision))
+	if resolveErr != nil {
+		return "", newError(ErrRevisionNotFound, resolveErr, "failed to resolve revision %q", revision)
+	}
+	commit, commitErr := repo.CommitObject(*hash)
+	if commitErr != nil {
+		return "", fmt.Errorf("failed to get commit %s of revision %q: %w", hash, revision, commitErr)
+	}
+
+	tagPrefix, modPath, modErr := pseudoVersionModule(repo, commit, opt)
+	if modErr != nil {
+		return "", modErr
+	}
+
+	base, exact, baseErr := pseudoVersionBase(repo, commit, tagPrefix, modPath)
+	if baseErr != nil {
+		return "", baseErr
+	}
+	if exact {
+		return base, nil
+	}
+
+	return formatPseudoVersion(base, modPath, commit), nil
+}
+
+// pseudoVersionModule returns the tag prefix and the module path, or an empty string if there is no Go module file, of
+// the module a pseudo-version is computed for.
+// Like the Go toolchain, the Go module file is read from the tree of the given commit so that the module path at the
+// revision is used. The tag prefix is derived from the module directory when set, which must then contain a Go module
+// file at the revision, or the configured tag prefix is used otherwise.
+func pseudoVersionModule(
+	repo *git.Repository,
+	commit *object.Commit,
+	opt *Options,
+) (tagPrefix, modPath string, err error) {
+	tree, treeErr := commit.Tree()
+	if treeErr != nil {
+		return "", "", fmt.Errorf("failed to get tree of commit %s: %w", commit.Hash, treeErr)
+	}
+	if opt.ModuleDir == "" {
+		modPath, err = treeModulePath(tree, ".")
+		return opt.TagPrefix, modPath, err
+	}
+
+	rel, relErr := repositoryModuleDir(repo, opt.ModuleDir)
+	if relErr != nil {
+		return "", "", newError(ErrInvalidModule, relErr, "failed to resolve module directory")
+	}
+	if modPath, err = treeModulePath(tree, rel); err != nil {
+		return "", "", newError(ErrInvalidModule, err, "failed to read module path")
+	}
+	if modPath == "" {
+		return "", "", newError(ErrInvalidModule, nil, "no Go module file in module directory %q of commit %s",
+			opt.ModuleDir, commit.Hash)
+	}
+
+	return relTagPrefix(rel, modPath), modPath, nil
+}
+
+// repositoryModuleDir returns the given module directory as slash-separated path relative to the root directory of the
+// given repository.
+// The module directory is resolved against the worktree while it must be relative to the root directory of the
+// repository when the repository is bare.
+func repositoryModuleDir(repo *git.Repository, moduleDir string) (string, error) {
+	wt, wtErr := repo.Worktree()
+	if wtErr != nil {
+		if errors.Is(wtErr, git.ErrIsBareRepository) {
+			return cleanModuleDir(moduleDir)
+		}
+		return "", fmt.Errorf("failed to get worktree: %w", wtErr)
+	}
+
+	return worktreeModuleDir(wt.Filesystem, moduleDir)
+}
+
+// treeModulePath reads the module path from the Go module file in the given slash-separated directory of the given
+// tree, or returns an empty string if there is none.
+func treeModulePath(tree *object.Tree, dir string) (string, error) {
+	goModFile := path.Join(dir, goModFileName)
+	file, fileErr := tree.File(goModFile)
+	if fileErr != nil {
+		if errors.Is(fileErr, object.ErrFileNotFound) || errors.Is(fileErr, object.ErrDirectoryNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get Go module file %q: %w", goModFile, fileErr)
+	}
+	data, contentErr := file.Contents()
+	if contentErr != nil {
+		return "", fmt.Errorf("failed to read Go module file %q: %w", goModFile, contentErr)
+	}
+
+	return parseModulePath([]byte(data), goModFile)
+}
+
+// pseudoVersionBase returns the highest version of all canonical SemVer tags with the given prefix that are reachable
+// from the given commit and compatible with the given module path, or an empty string if there is none.
+// If the commit itself is tagged, the highest version of these tags is returned with the exact flag set to true.
+func pseudoVersionBase(
+	repo *git.Repository,
+	commit *object.Commit,
+	tagPrefix, modPath string,
+) (base string, exact bool, err error) {
+	tagged := make(map[plumbing.Hash][]*semver.Version)
+	tagIterator, repoTagsErr := repo.Tags()
+	if repoTagsErr != nil {
+		return "", false, fmt.Errorf("failed to get all tag references: %w", repoTagsErr)
+	}
+	defer tagIterator.Close()
+	tagIterErr := tagIterator.ForEach(func(tag *plumbing.Reference) error {
+		name := tag.Name().Short()
+		if !strings.HasPrefix(name, tagPrefix) {
+			return nil
+		}
+		semVersion, ok := canonicalModuleVersion(strings.TrimPrefix(name, tagPrefix), modPath)
+		if !ok {
+			return nil
+		}
+		commitHash := tag.Hash()
+		tagObject, tagObjectErr := repo.TagObject(tag.Hash())
+		switch {
+		case tagObjectErr == nil:
+			tagCommit, tagCommitErr := tagObject.Commit()
+			if tagCommitErr != nil {
+				if errors.Is(tagCommitErr, object.ErrUnsupportedObject) {
+					return nil
+				}
+				return fmt.Errorf("failed to get commit of tag %s: %w", name, tagCommitErr)
+			}
+			commitHash = tagCommit.Hash
+		case !errors.Is(tagObjectErr, plumbing.ErrObjectNotFound):
+			return fmt.Errorf("failed to get tag object of tag %s: %w", name, tagObjectErr)
+		}
+		tagged[commitHash] = append(tagged[commitHash], semVersion)
+		return nil
+	})
+	if tagIterErr != nil {
+		return "", false, fmt.Errorf("failed to iterate over tags: %w", tagIterErr)
+	}
+
+	if versions, ok := tagged[commit.Hash]; ok {
+		return formatModuleVersion(maxVersion(versions), modPath), true, nil
+	}
+	if len(tagged) == 0 {
+		return "", false, nil
+	}
+
+	var reachable []*semver.Version
+	commitIterator := object.NewCommitPreorderIter(commit, nil, nil)
+	defer commitIterator.Close()
+	if commitIterErr := commitIterator.ForEach(func(c *object.Commit) error {
+		reachable = append(reachable, tagged[c.Hash]...)
+		return nil
+	}); commitIterErr != nil {
+		return "", false, fmt.Errorf("failed to iterate over commits: %w", commitIterErr)
+	}
+	if len(reachable) == 0 {
+		return "", false, nil
+	}
+
+	return formatModuleVersion(maxVersion(reachable), modPath), false, nil
+}
+
+// canonicalModuleVersion parses the given version and checks if it is a canonical SemVer version with a leading "v",
+// without build metadata, that is compatible with the major version of the given module path.
+// When the module path is empty, which means there is no Go module file, all major versions are compatible.
+func canonicalModuleVersion(v, modPath string) (*semver.Version, bool) {
+	if !strings.HasPrefix(v, "v") {
+		return nil, false
+	}
+	semVersion, err := semver.StrictNewVersion(strings.TrimPrefix(v, "v"))
+	if err != nil || semVersion.Metadata() != "" {
+		return nil, false
+	}
+	if modPath == "" {
+		return semVersion, true
+	}
+	major := moduleMajorVersion(modPath)
+	if major == 0 {
+		return semVersion, semVersion.Major() <= 1
+	}
+
+	return semVersion, semVersion.Major() == major
+}
+
+// moduleMajorVersion returns the major version of the given module path, or 0 if it has no major version suffix.
+func moduleMajorVersion(modPath string) uint64 {
+	elem := path.Base(modPath)
+	if !isMajorVersionSuffix(elem) {
+		return 0
+	}
+	major, _ := strconv.ParseUint(elem[1:], 10, 64)
+
+	return major
+}
+
+// formatModuleVersion formats the given version with a leading "v" and appends the "+incompatible" suffix when the
+// major version is 2 or higher and there is no Go module file.
+func formatModuleVersion(v *semver.Version, modPath string) string {
+	formatted := "v" + v.String()
+	if modPath == "" && v.Major() >= 2 {
+		formatted += incompatibleSuffix
+	}
+
+	return formatted
+}
+
+// formatPseudoVersion formats the pseudo-version of the given commit based on the given version.
+func formatPseudoVersion(base, modPath string, commit *object.Commit) string {
+	suffix := fmt.Sprintf("%s-%s",
+		commit.Committer.When.UTC().Format(PseudoVersionTimeFormat), commit.Hash.String()[:PseudoVersionHashLength])
+
+	if base == "" {
+		major := moduleMajorVersion(modPath)
+		return fmt.Sprintf("v%d.0.0-%s", major, suffix)
+	}
+
+	incompatible := strings.HasSuffix(base, incompatibleSuffix)
+	base = strings.TrimSuffix(base, incompatibleSuffix)
+	// The base version has been validated as strict SemVer version before.
+	semVersion := semver.MustParse(base)
+	var pseudo string
+	if semVersion.Prerelease() != "" {
+		pseudo = fmt.Sprintf("%s.0.%s", base, suffix)
+	} else {
+		pseudo = fmt.Sprintf("v%d.%d.%d-0.%s", semVersion.Major(), semVersion.Minor(), semVersion.Patch()+1, suffix)
+	}
+	if incompatible {
+		pseudo += incompatibleSuffix
+	}
+
+	return pseudo
+}
+
+// maxVersion returns the highest of the given versions.
+func maxVersion(versions []*semver.Version) *semver.Version {
+	var highest *semver.Version
+	for _, v := range versions {
+		if highest == nil || v.GreaterThan(highest) {
+			highest = v
+		}
+	}
+
+	return highest
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+func TestPseudoVersion(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.stageFile("go.mod", "module example.com/repo\n")
+	first := tr.commit("first")
+
+	pseudo, err := glGit.PseudoVersion(tr.path, "")
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("v0.0.0-20201121120100-%s", first.String()[:12]), pseudo)
+
+	tr.tag("v1.2.3", first, "")
+	tr.tag("v2.0.0", first, "v2.0.0")
+	second := tr.commit("second")
+
+	pseudo, err = glGit.PseudoVersion(tr.path, "HEAD")
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("v1.2.4-0.20201121120200-%s", second.String()[:12]), pseudo)
+
+	tr.tag("v1.3.0-rc.1", second, "v1.3.0-rc.1")
+	third := tr.commit("third")
+
+	pseudo, err = glGit.PseudoVersion(tr.path, third.String())
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("v1.3.0-rc.1.0.20201121120300-%s", third.String()[:12]), pseudo)
+
+	pseudo, err = glGit.PseudoVersion(tr.path, second.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0-rc.1", pseudo)
+
+	_, err = glGit.PseudoVersion(tr.path, "non-existing-revision")
+	assert.Error(t, err)
+}
+
+func TestPseudoVersion_ModulePathAtRevision(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.stageFile("go.mod", "module example.com/repo\n")
+	first := tr.commit("first")
+	tr.tag("v1.0.0", first, "")
+	tr.tag("v2.0.0", first, "")
+	second := tr.commit("second")
+	tr.stageFile("go.mod", "module example.com/repo/v2\n")
+	tr.commit("third")
+	fourth := tr.commit("fourth")
+	// Uncommitted changes of the Go module file are not used.
+	tr.writeFile("go.mod", "module example.com/repo/v3\n")
+
+	// The module path at the revision determines which tags are compatible.
+	pseudo, err := glGit.PseudoVersion(tr.path, second.String())
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("v1.0.1-0.20201121120200-%s", second.String()[:12]), pseudo)
+	pseudo, err = glGit.PseudoVersion(tr.path, "")
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("v2.0.1-0.20201121120400-%s", fourth.String()[:12]), pseudo)
+}
+
+func TestPseudoVersionFromRepository(t *testing.T) {
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	if err != nil {
+		assert.FailNow(t, "failed to initialize in-memory repository", "error: %v", err)
+	}
+	tr := &testRepository{repo: repo, t: t}
+	for name, modPath := range map[string]string{"go.mod": "example.com/repo/v2", "mod/go.mod": "example.com/repo/mod"} {
+		if err = util.WriteFile(fs, name, []byte("module "+modPath+"\n"), 0o600); err != nil {
+			assert.FailNow(t, "failed to write Go module file", "file: %q\nerror: %v", name, err)
+		}
+		if _, err = tr.worktree().Add(name); err != nil {
+			assert.FailNow(t, "failed to add Go module file", "file: %q\nerror: %v", name, err)
+		}
+	}
+	first := tr.commitStaged("first")
+	for _, tag := range []string{"v1.0.0", "v2.0.0", "v3.0.0", "mod/v1.2.0"} {
+		tr.tag(tag, first, "")
+	}
+	second := tr.commitStaged("second")
+
+	bare, err := git.Open(repo.Storer, nil)
+	if err != nil {
+		assert.FailNow(t, "failed to open bare repository", "error: %v", err)
+	}
+	for _, r := range []*git.Repository{repo, bare} {
+		// Only tags whose major version is compatible with the module path are considered.
+		pseudo, pseudoErr := glGit.PseudoVersionFromRepository(r, "")
+		assert.NoError(t, pseudoErr)
+		assert.Equal(t, fmt.Sprintf("v2.0.1-0.20201121120200-%s", second.String()[:12]), pseudo)
+
+		pseudo, pseudoErr = glGit.PseudoVersionFromRepository(r, "", glGit.WithModuleDir("mod"))
+		assert.NoError(t, pseudoErr)
+		assert.Equal(t, fmt.Sprintf("v1.2.1-0.20201121120200-%s", second.String()[:12]), pseudo)
+
+		_, pseudoErr = glGit.PseudoVersionFromRepository(r, "", glGit.WithModuleDir("docs"))
+		assert.True(t, errors.Is(pseudoErr, glGit.ErrInvalidModule))
+	}
+
+	_, err = glGit.PseudoVersionFromRepository(nil, "")
+	assert.True(t, errors.Is(err, glGit.ErrRepositoryNotFound))
+}
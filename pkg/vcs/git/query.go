@@ -0,0 +1,506 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// MergeFilter defines how merge commits are handled by a log query.
+type MergeFilter int
+
+const (
+	// MergesInclude includes merge commits like all other commits.
+	MergesInclude MergeFilter = iota
+
+	// MergesExclude excludes merge commits.
+	// This is the equivalent of the "--no-merges" flag of the Git "log" command.
+	MergesExclude
+
+	// MergesOnly only includes merge commits.
+	// This is the equivalent of the "--merges" flag of the Git "log" command.
+	MergesOnly
+)
+
+// errTreeSame is used to stop the iteration over parents when a commit has not changed the paths compared to a parent.
+var errTreeSame = errors.New("commit is the same as its parent for the paths")
+
+// LogQuery is a query for commits of a Git repository modelled after the Git "log" command.
+// All filters are combined so that only commits matching all of them are returned.
+type LogQuery struct {
+	// Author is a regular expression that is matched against the author in the "Name <email>" format.
+	// This is the equivalent of the "--author" flag of the Git "log" command.
+	Author string
+
+	// Committer is a regular expression that is matched against the committer in the "Name <email>" format.
+	// This is the equivalent of the "--committer" flag of the Git "log" command.
+	Committer string
+
+	// Limit is the maximum amount of commits to return, or 0 to return all commits.
+	// This is the equivalent of the "--max-count" flag of the Git "log" command.
+	Limit int
+
+	// Mailmap resolves the author and committer to their canonical identities before they are matched and returned,
+	// unless nil.
+	// This is the equivalent of the "--use-mailmap" flag of the Git "log" command.
+	Mailmap *Mailmap
+
+	// Merges defines how merge commits are handled.
+	Merges MergeFilter
+
+	// Offset is the amount of matching commits to skip before commits are returned, e.g. to query the next page.
+	// This is the equivalent of the "--skip" flag of the Git "log" command.
+	Offset int
+
+	// Paths are paths of files and directories, relative to the root directory of the repository, of which at least one
+	// must be changed by a commit.
+	// Like the Git "log" command without history simplification, merge commits are only included when they changed the
+	// paths compared to all of their parents.
+	Paths []string
+
+	// Range is the revision range of commits, like "v1.0.0..HEAD" for commits reachable from HEAD, but not from the tag,
+	// or "main...feature" for commits reachable from either revision, but not from both.
+	// Omitted revisions of a range default to HEAD, a single revision selects all commits reachable from it and all
+	// commits reachable from HEAD are selected when empty. The revisions can be any revision supported by the Git
+	// "rev-parse" command, like a branch, tag or commit hash.
+	Range string
+
+	// Since only includes commits whose committer time is not before the given time, unless it is the zero time.
+	// This is the equivalent of the "--since" flag of the Git "log" command.
+	Since time.Time
+
+	// Until only includes commits whose committer time is not after the given time, unless it is the zero time.
+	// This is the equivalent of the "--until" flag of the Git "log" command.
+	Until time.Time
+}
+
+// LogCommit is a lightweight representation of a commit returned by a log query.
+type LogCommit struct {
+	// Author is the signature of the author.
+	Author object.Signature
+
+	// Committer is the signature of the committer.
+	Committer object.Signature
+
+	// Hash is the hash of the commit.
+	Hash plumbing.Hash
+
+	// Message is the full commit message.
+	Message string
+
+	// ParentHashes are the hashes of the parent commits.
+	ParentHashes []plumbing.Hash
+}
+
+// IsMerge checks if the commit is a merge commit with more than one parent.
+func (c *LogCommit) IsMerge() bool {
+	return len(c.ParentHashes) > 1
+}
+
+// ShortHash returns the commit hash shortened to the DefaultHashAbbrevLength.
+func (c *LogCommit) ShortHash() string {
+	return abbrevHash(c.Hash, DefaultHashAbbrevLength)
+}
+
+// Subject returns the first line of the commit message.
+func (c *LogCommit) Subject() string {
+	subject := strings.TrimSpace(c.Message)
+	if i := strings.IndexByte(subject, '\n'); i >= 0 {
+		subject = strings.TrimSpace(subject[:i])
+	}
+
+	return subject
+}
+
+// LogPage is a page of commits returned by a log query.
+type LogPage struct {
+	// Commits are the matching commits ordered by committer time, newest first.
+	Commits []*LogCommit
+
+	// HasMore indicates whether there are more matching commits after this page that can be queried by increasing the
+	// offset by the amount of returned commits.
+	HasMore bool
+}
+
+// logFilter is a compiled log query.
+type logFilter struct {
+	author    *regexp.Regexp
+	committer *regexp.Regexp
+	paths     []string
+	query     *LogQuery
+}
+
+// QueryLog queries the commits of a Git repository.
+// The DetectDotGit and CeilingDirs options are used to find the repository while all other options are ignored.
+func QueryLog(repositoryPath string, query *LogQuery, opts ...Option) (*LogPage, error) {
+	repo, repoOpenErr := openRepository(repositoryPath, NewOptions(opts...))
+	if repoOpenErr != nil {
+		return nil, repoOpenErr
+	}
+
+	return QueryLogFromRepository(repo, query)
+}
+
+// QueryLogFromRepository is like QueryLog but queries the commits of the given already opened repository.
+// All commits reachable from HEAD are returned when the given query is nil.
+func QueryLogFromRepository(repo *git.Repository, query *LogQuery) (*LogPage, error) {
+	if repo == nil {
+		return nil, newError(ErrRepositoryNotFound, nil, "repository must not be nil")
+	}
+	if query == nil {
+		query = &LogQuery{}
+	}
+	filter, filterErr := newLogFilter(query)
+	if filterErr != nil {
+		return nil, filterErr
+	}
+
+	includes, excludes, symmetric, rangeErr := resolveRange(repo, query.Range)
+	if rangeErr != nil {
+		return nil, rangeErr
+	}
+	index, closeIndex := openCommitNodeIndex(repo)
+	defer closeIndex()
+
+	excluded, excludedErr := excludedCommits(index, includes, excludes, symmetric)
+	if excludedErr != nil {
+		return nil, excludedErr
+	}
+
+	page := &LogPage{}
+	skipped := 0
+	walkErr := walkCommits(index, includes, excluded, func(node commitgraph.CommitNode) error {
+		commit, matchErr := filter.match(node)
+		if matchErr != nil || commit == nil {
+			return matchErr
+		}
+		if skipped < query.Offset {
+			skipped++
+			return nil
+		}
+		if query.Limit > 0 && len(page.Commits) == query.Limit {
+			page.HasMore = true
+			return storer.ErrStop
+		}
+		page.Commits = append(page.Commits, commit)
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	return page, nil
+}
+
+// newLogFilter compiles the given log query.
+func newLogFilter(query *LogQuery) (*logFilter, error) {
+	if query.Limit < 0 || query.Offset < 0 {
+		return nil, newError(ErrInvalidOption, nil, "limit and offset must not be negative: %d, %d", query.Limit, query.Offset)
+	}
+	if query.Merges < MergesInclude || query.Merges > MergesOnly {
+		return nil, newError(ErrInvalidOption, nil, "invalid merge filter: %d", query.Merges)
+	}
+
+	filter := &logFilter{query: query}
+	var compileErr error
+	if query.Author != "" {
+		if filter.author, compileErr = regexp.Compile(query.Author); compileErr != nil {
+			return nil, newError(ErrInvalidOption, compileErr, "invalid author pattern %q", query.Author)
+		}
+	}
+	if query.Committer != "" {
+		if filter.committer, compileErr = regexp.Compile(query.Committer); compileErr != nil {
+			return nil, newError(ErrInvalidOption, compileErr, "invalid committer pattern %q", query.Committer)
+		}
+	}
+	for _, p := range query.Paths {
+		p = strings.Trim(path.Clean(strings.ReplaceAll(p, "\\", "/")), "/")
+		if p == "." || p == "" {
+			// The root directory matches all changes.
+			filter.paths = nil
+			break
+		}
+		filter.paths = append(filter.paths, p)
+	}
+
+	return filter, nil
+}
+
+// match returns the commit of the given node if it matches the query, otherwise nil.
+// The cheap filters are checked first so that the commit object is only decoded and diffed when necessary.
+func (f *logFilter) match(node commitgraph.CommitNode) (*LogCommit, error) {
+	merge := node.NumParents() > 1
+	if (f.query.Merges == MergesExclude && merge) || (f.query.Merges == MergesOnly && !merge) {
+		return nil, nil
+	}
+	when := node.CommitTime()
+	if (!f.query.Since.IsZero() && when.Before(f.query.Since)) || (!f.query.Until.IsZero() && when.After(f.query.Until)) {
+		return nil, nil
+	}
+
+	commit, commitErr := node.Commit()
+	if commitErr != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", node.ID(), commitErr)
+	}
+	author := f.query.Mailmap.ResolveSignature(commit.Author)
+	committer := f.query.Mailmap.ResolveSignature(commit.Committer)
+	if f.author != nil && !f.author.MatchString(formatSignature(&author)) {
+		return nil, nil
+	}
+	if f.committer != nil && !f.committer.MatchString(formatSignature(&committer)) {
+		return nil, nil
+	}
+	if len(f.paths) > 0 {
+		touched, touchErr := f.touchesPaths(commit)
+		if touchErr != nil || !touched {
+			return nil, touchErr
+		}
+	}
+
+	return &LogCommit{
+		Author:       author,
+		Committer:    committer,
+		Hash:         commit.Hash,
+		Message:      commit.Message,
+		ParentHashes: commit.ParentHashes,
+	}, nil
+}
+
+// matchesPath checks if the file with the given name is one of the paths or within one of them.
+func (f *logFilter) matchesPath(name string) bool {
+	for _, p := range f.paths {
+		if name == p || strings.HasPrefix(name, p+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// touchesPaths checks if the given commit changed at least one of the paths compared to all of its parents.
+// A root commit touches the paths when they exist in its tree.
+// Only the tree entries of the paths are compared, which are the hashes of the subtrees for directories, instead of
+// diffing the whole trees so that the costs do not depend on the size of the trees.
+func (f *logFilter) touchesPaths(commit *object.Commit) (bool, error) {
+	entries, entriesErr := f.pathEntries(commit)
+	if entriesErr != nil {
+		return false, entriesErr
+	}
+	if commit.NumParents() == 0 {
+		for _, entry := range entries {
+			if entry != nil {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	parentsErr := commit.Parents().ForEach(func(parent *object.Commit) error {
+		parentEntries, parentEntriesErr := f.pathEntries(parent)
+		if parentEntriesErr != nil {
+			return parentEntriesErr
+		}
+		for i, entry := range entries {
+			if !sameTreeEntry(entry, parentEntries[i]) {
+				return nil
+			}
+		}
+		// The commit is the same as this parent for the paths.
+		return errTreeSame
+	})
+	switch {
+	case errors.Is(parentsErr, errTreeSame):
+		return false, nil
+	case parentsErr != nil:
+		return false, parentsErr
+	}
+
+	return true, nil
+}
+
+// pathEntries returns the tree entries of the paths in the tree of the given commit in the order of the paths, or nil
+// for paths that do not exist.
+func (f *logFilter) pathEntries(commit *object.Commit) ([]*object.TreeEntry, error) {
+	tree, treeErr := commit.Tree()
+	if treeErr != nil {
+		return nil, fmt.Errorf("failed to get tree of commit %s: %w", commit.Hash, treeErr)
+	}
+
+	entries := make([]*object.TreeEntry, len(f.paths))
+	for i, p := range f.paths {
+		entry, findErr := tree.FindEntry(p)
+		switch {
+		case findErr == nil:
+			entries[i] = entry
+		case errors.Is(findErr, object.ErrEntryNotFound), errors.Is(findErr, object.ErrDirectoryNotFound),
+			errors.Is(findErr, plumbing.ErrObjectNotFound):
+			// The path does not exist or one of its parent directories is a file.
+		default:
+			return nil, fmt.Errorf("failed to find %q in tree of commit %s: %w", p, commit.Hash, findErr)
+		}
+	}
+
+	return entries, nil
+}
+
+// sameTreeEntry checks if the given tree entries, which are nil for paths that do not exist, have the same object and
+// mode.
+func sameTreeEntry(a, b *object.TreeEntry) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Hash == b.Hash && a.Mode == b.Mode
+}
+
+// formatSignature formats the given signature in the "Name <email>" format.
+func formatSignature(sig *object.Signature) string {
+	return fmt.Sprintf("%s <%s>", sig.Name, sig.Email)
+}
+
+// resolveRange resolves the revisions of the given range to the hashes of the commits to include and exclude.
+func resolveRange(repo *git.Repository, rng string) (includes, excludes []plumbing.Hash, symmetric bool, err error) {
+	var left, right string
+	switch {
+	case strings.Contains(rng, "..."):
+		symmetric = true
+		parts := strings.SplitN(rng, "...", 2)
+		left, right = parts[0], parts[1]
+	case strings.Contains(rng, ".."):
+		parts := strings.SplitN(rng, "..", 2)
+		left, right = parts[0], parts[1]
+	default:
+		hash, resolveErr := resolveRevision(repo, rng)
+		if resolveErr != nil {
+			return nil, nil, false, resolveErr
+		}
+		return []plumbing.Hash{hash}, nil, false, nil
+	}
+
+	leftHash, resolveErr := resolveRevision(repo, left)
+	if resolveErr != nil {
+		return nil, nil, false, resolveErr
+	}
+	rightHash, resolveErr := resolveRevision(repo, right)
+	if resolveErr != nil {
+		return nil, nil, false, resolveErr
+	}
+	if symmetric {
+		return []plumbing.Hash{leftHash, rightHash}, nil, true, nil
+	}
+
+	return []plumbing.Hash{rightHash}, []plumbing.Hash{leftHash}, false, nil
+}
+
+// resolveRevision resolves the given revision to the hash of a commit, or HEAD when the revision is empty.
+func resolveRevision(repo *git.Repository, revision string) (plumbing.Hash, error) {
+	if revision == "" {
+		revision = plumbing.HEAD.String()
+	}
+	hash, resolveErr := repo.ResolveRevision(plumbing.Revision(revision))
+	if resolveErr != nil {
+		return plumbing.ZeroHash, newError(ErrRevisionNotFound, resolveErr, "failed to resolve revision %q", revision)
+	}
+
+	return *hash, nil
+}
+
+// excludedCommits returns the hashes of all commits that are excluded by the range.
+// These are all commits reachable from the excluded commits, or for a symmetric range all commits that are reachable
+// from both included commits.
+func excludedCommits(
+	index commitgraph.CommitNodeIndex,
+	includes, excludes []plumbing.Hash,
+	symmetric bool,
+) (map[plumbing.Hash]bool, error) {
+	if !symmetric {
+		excluded := make(map[plumbing.Hash]bool)
+		if len(excludes) == 0 {
+			return excluded, nil
+		}
+		return excluded, walkCommits(index, excludes, nil, func(node commitgraph.CommitNode) error {
+			excluded[node.ID()] = true
+			return nil
+		})
+	}
+
+	left := make(map[plumbing.Hash]bool)
+	if walkErr := walkCommits(index, includes[:1], nil, func(node commitgraph.CommitNode) error {
+		left[node.ID()] = true
+		return nil
+	}); walkErr != nil {
+		return nil, walkErr
+	}
+	both := make(map[plumbing.Hash]bool)
+	walkErr := walkCommits(index, includes[1:], nil, func(node commitgraph.CommitNode) error {
+		if left[node.ID()] {
+			both[node.ID()] = true
+		}
+		return nil
+	})
+
+	return both, walkErr
+}
+
+// walkCommits calls the given function for all commits reachable from the given commits, ordered by committer time,
+// newest first, while commits in the given excluded set are skipped together with their parents, unless reachable
+// otherwise.
+// The walk stops without an error when the function returns storer.ErrStop.
+func walkCommits(
+	index commitgraph.CommitNodeIndex,
+	starts []plumbing.Hash,
+	excluded map[plumbing.Hash]bool,
+	fn func(node commitgraph.CommitNode) error,
+) error {
+	var queue commitQueue
+	seen := make(map[plumbing.Hash]bool)
+	seq := 0
+	push := func(hash plumbing.Hash) error {
+		if seen[hash] || excluded[hash] {
+			return nil
+		}
+		seen[hash] = true
+		node, nodeErr := index.Get(hash)
+		if nodeErr != nil {
+			return fmt.Errorf("failed to get commit %s: %w", hash, nodeErr)
+		}
+		heap.Push(&queue, &graphCommit{node: node, seq: seq})
+		seq++
+		return nil
+	}
+
+	for _, hash := range starts {
+		if pushErr := push(hash); pushErr != nil {
+			return pushErr
+		}
+	}
+	for queue.Len() > 0 {
+		gc, _ := heap.Pop(&queue).(*graphCommit)
+		if fnErr := fn(gc.node); fnErr != nil {
+			if errors.Is(fnErr, storer.ErrStop) {
+				return nil
+			}
+			return fnErr
+		}
+		for _, parentHash := range gc.node.ParentHashes() {
+			if pushErr := push(parentHash); pushErr != nil {
+				return pushErr
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+// commitFile writes the file with the given name and commits it with the given message and author.
+func (tr *testRepository) commitFile(name, msg string, author *object.Signature) plumbing.Hash {
+	tr.t.Helper()
+	wt, err := tr.repo.Worktree()
+	if err != nil {
+		assert.FailNow(tr.t, "failed to get worktree", "error: %v", err)
+	}
+
+	tr.commits++
+	if err = os.MkdirAll(filepath.Dir(filepath.Join(tr.path, name)), 0o700); err != nil {
+		assert.FailNow(tr.t, "failed to create directory", "file: %q\nerror: %v", name, err)
+	}
+	if err = os.WriteFile(filepath.Join(tr.path, name), []byte(msg), 0o600); err != nil {
+		assert.FailNow(tr.t, "failed to write file", "file: %q\nerror: %v", name, err)
+	}
+	if _, err = wt.Add(name); err != nil {
+		assert.FailNow(tr.t, "failed to add file", "file: %q\nerror: %v", name, err)
+	}
+	committer := tr.signature()
+	author.When = committer.When
+	hash, err := wt.Commit(msg, &git.CommitOptions{Author: author, Committer: committer})
+	if err != nil {
+		assert.FailNow(tr.t, "failed to commit", "message: %q\nerror: %v", msg, err)
+	}
+
+	return hash
+}
+
+// logHashes returns the hashes of the commits of the given page.
+func logHashes(page *glGit.LogPage) []plumbing.Hash {
+	hashes := make([]plumbing.Hash, 0, len(page.Commits))
+	for _, c := range page.Commits {
+		hashes = append(hashes, c.Hash)
+	}
+
+	return hashes
+}
+
+func TestQueryLog(t *testing.T) {
+	tr := newTestRepository(t)
+	arctic := &object.Signature{Name: "Arctic Ice", Email: "arctic@example.com"}
+	first := tr.commitFile("README.md", "docs: initial readme\n\nWith a body.", tr.signature())
+	tr.tag("v1.0.0", first, "v1.0.0")
+	second := tr.commitFile("pkg/a/a.go", "feat: add package a", arctic)
+	third := tr.commitFile("pkg/b/b.go", "feat: add package b", tr.signature())
+	fourth := tr.commitFile("pkg/a/a_test.go", "test: add tests for package a", arctic)
+
+	page, err := glGit.QueryLog(tr.path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{fourth, third, second, first}, logHashes(page))
+	assert.False(t, page.HasMore)
+	assert.Equal(t, "docs: initial readme", page.Commits[3].Subject())
+	assert.Equal(t, first.String()[:8], page.Commits[3].ShortHash())
+
+	testCases := []struct {
+		expected []plumbing.Hash
+		name     string
+		query    *glGit.LogQuery
+	}{
+		{name: "range", query: &glGit.LogQuery{Range: "v1.0.0..HEAD"}, expected: []plumbing.Hash{fourth, third, second}},
+		{name: "range with omitted end", query: &glGit.LogQuery{Range: "v1.0.0.."}, expected: []plumbing.Hash{fourth, third, second}},
+		{name: "single revision", query: &glGit.LogQuery{Range: "HEAD~2"}, expected: []plumbing.Hash{second, first}},
+		{name: "symmetric range", query: &glGit.LogQuery{Range: "HEAD~2...HEAD"}, expected: []plumbing.Hash{fourth, third}},
+		{name: "directory", query: &glGit.LogQuery{Paths: []string{"pkg/a/"}}, expected: []plumbing.Hash{fourth, second}},
+		{name: "file", query: &glGit.LogQuery{Paths: []string{"./README.md", "pkg/b/b.go"}}, expected: []plumbing.Hash{third, first}},
+		{name: "path prefix is no directory", query: &glGit.LogQuery{Paths: []string{"pkg/a/a"}}},
+		{name: "path below file", query: &glGit.LogQuery{Paths: []string{"README.md/a.go"}}},
+		{name: "author", query: &glGit.LogQuery{Author: "arctic@"}, expected: []plumbing.Hash{fourth, second}},
+		{name: "committer", query: &glGit.LogQuery{Committer: "^Sven Greb <"}, expected: []plumbing.Hash{fourth, third, second, first}},
+		{
+			name:     "time window",
+			query:    &glGit.LogQuery{Since: tr.signatureAt(2).When, Until: tr.signatureAt(3).When},
+			expected: []plumbing.Hash{third, second},
+		},
+		{name: "merges only", query: &glGit.LogQuery{Merges: glGit.MergesOnly}},
+		{name: "combined", query: &glGit.LogQuery{Author: "Arctic", Paths: []string{"pkg"}, Range: "HEAD~1"}, expected: []plumbing.Hash{second}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := glGit.QueryLog(tr.path, tc.query)
+			if !assert.NoError(t, err) {
+				return
+			}
+			if len(tc.expected) == 0 {
+				assert.Empty(t, page.Commits)
+				return
+			}
+			assert.Equal(t, tc.expected, logHashes(page))
+		})
+	}
+}
+
+func TestQueryLog_PathChanges(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.stageFile("pkg/a/a.go", "package a")
+	added := tr.commitStaged("feat: add package a")
+	tr.stageFile("pkg/b/b.go", "package b")
+	tr.commitStaged("feat: add package b")
+	tr.stageFile("pkg/a/a.go", "package a\n")
+	modified := tr.commitStaged("fix: format package a")
+	if _, err := tr.worktree().Remove("pkg/a/a.go"); err != nil {
+		assert.FailNow(t, "failed to remove file", "error: %v", err)
+	}
+	removed := tr.commitStaged("refactor: remove package a")
+
+	for _, paths := range [][]string{{"pkg/a"}, {"pkg/a/a.go"}, {"pkg/a", "pkg/c"}} {
+		page, err := glGit.QueryLog(tr.path, &glGit.LogQuery{Paths: paths})
+		if assert.NoError(t, err, "paths: %v", paths) {
+			assert.Equal(t, []plumbing.Hash{removed, modified, added}, logHashes(page), "paths: %v", paths)
+		}
+	}
+}
+
+func TestQueryLog_Pagination(t *testing.T) {
+	tr := newTestRepository(t)
+	var hashes []plumbing.Hash
+	for i := 0; i < 5; i++ {
+		hashes = append([]plumbing.Hash{tr.commit("commit")}, hashes...)
+	}
+
+	var queried []plumbing.Hash
+	query := &glGit.LogQuery{Limit: 2}
+	for {
+		page, err := glGit.QueryLog(tr.path, query)
+		if !assert.NoError(t, err) {
+			return
+		}
+		queried = append(queried, logHashes(page)...)
+		if !page.HasMore {
+			break
+		}
+		query.Offset += len(page.Commits)
+	}
+	assert.Equal(t, hashes, queried)
+}
+
+func TestQueryLog_Merges(t *testing.T) {
+	tr := newTestRepository(t)
+	first := tr.commit("first")
+	mainline := tr.commit("mainline", first)
+	feature := tr.commit("feature", first)
+	merge := tr.commit("merge", mainline, feature)
+
+	page, err := glGit.QueryLog(tr.path, &glGit.LogQuery{Merges: glGit.MergesOnly})
+	assert.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{merge}, logHashes(page))
+	assert.True(t, page.Commits[0].IsMerge())
+
+	page, err = glGit.QueryLog(tr.path, &glGit.LogQuery{Merges: glGit.MergesExclude})
+	assert.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{feature, mainline, first}, logHashes(page))
+
+	page, err = glGit.QueryLog(tr.path, &glGit.LogQuery{Range: "HEAD^1...HEAD^2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{feature, mainline}, logHashes(page))
+}
+
+func TestQueryLog_FailWithInvalidQuery(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.commit("first")
+
+	for _, query := range []*glGit.LogQuery{
+		{Author: "("},
+		{Committer: "["},
+		{Limit: -1},
+		{Merges: glGit.MergeFilter(42)},
+	} {
+		_, err := glGit.QueryLog(tr.path, query)
+		assert.True(t, errors.Is(err, glGit.ErrInvalidOption), "query: %+v", query)
+	}
+
+	_, err := glGit.QueryLog(tr.path, &glGit.LogQuery{Range: "missing..HEAD"})
+	assert.True(t, errors.Is(err, glGit.ErrRevisionNotFound))
+}
+
+// signatureAt returns the signature of the commit with the given number.
+func (tr *testRepository) signatureAt(n int) *object.Signature {
+	sig := tr.signature()
+	sig.When = sig.When.Add(time.Duration(n-tr.commits) * time.Minute)
+	return sig
+}
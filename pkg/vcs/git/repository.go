@@ -0,0 +1,111 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+
+	glFS "github.com/svengreb/golib/pkg/io/fs"
+)
+
+// bareRepositoryEntries are the names of the entries that must exist in the directory of a bare repository.
+var bareRepositoryEntries = []string{"HEAD", "objects", "refs"}
+
+// openRepository opens the repository at the given path or, when enabled through the DetectDotGit option, the
+// repository whose root directory is found by searching upwards from the given path.
+// The ".git" directory of the repository can also be a ".git" file pointing to the Git directory of a linked worktree
+// or submodule, whose "commondir" file is taken into account to find the objects and references shared with the main
+// worktree.
+func openRepository(repositoryPath string, opt *Options) (*git.Repository, error) {
+	root := repositoryPath
+	if opt.DetectDotGit {
+		var findErr error
+		if root, findErr = findRepositoryRoot(repositoryPath, opt.CeilingDirs); findErr != nil {
+			return nil, findErr
+		}
+	}
+
+	repo, repoOpenErr := git.PlainOpenWithOptions(root, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	switch {
+	case errors.Is(repoOpenErr, git.ErrRepositoryNotExists):
+		return nil, newError(ErrRepositoryNotFound, repoOpenErr, "failed to open repository at path %q", root)
+	case repoOpenErr != nil:
+		return nil, fmt.Errorf("failed to open repository at path %q: %w", root, repoOpenErr)
+	}
+
+	return repo, nil
+}
+
+// findRepositoryRoot searches for the root directory of a repository starting from the given path, or its parent
+// directory when it is a file, upwards to the root of the filesystem like the Git "rev-parse --show-toplevel" command.
+// A directory is the root of a repository when it contains a ".git" directory or file or when it is the directory of a
+// bare repository.
+// Like the "GIT_CEILING_DIRECTORIES" environment variable of Git, the search does not continue into any of the given
+// ceiling directories while the starting directory itself is always searched.
+func findRepositoryRoot(startPath string, ceilingDirs []string) (string, error) {
+	dir, absErr := filepath.Abs(startPath)
+	if absErr != nil {
+		return "", fmt.Errorf("failed to get absolute path of %q: %w", startPath, absErr)
+	}
+	info, statErr := os.Stat(dir)
+	switch {
+	case os.IsNotExist(statErr):
+		return "", newError(ErrRepositoryNotFound, statErr, "path %q does not exist", startPath)
+	case statErr != nil:
+		return "", fmt.Errorf("failed to get file information of %q: %w", startPath, statErr)
+	}
+	if !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	ceilings := make(map[string]bool, len(ceilingDirs))
+	for _, ceilingDir := range ceilingDirs {
+		absCeilingDir, ceilingAbsErr := filepath.Abs(ceilingDir)
+		if ceilingAbsErr != nil {
+			return "", fmt.Errorf("failed to get absolute path of ceiling directory %q: %w", ceilingDir, ceilingAbsErr)
+		}
+		ceilings[absCeilingDir] = true
+	}
+
+	for {
+		isRoot, rootErr := isRepositoryRoot(dir)
+		if rootErr != nil {
+			return "", rootErr
+		}
+		if isRoot {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir || ceilings[parent] {
+			return "", newError(ErrRepositoryNotFound, git.ErrRepositoryNotExists,
+				"no repository found in %q or any of its parent directories", startPath)
+		}
+		dir = parent
+	}
+}
+
+// isRepositoryRoot checks if the given directory is the root directory of a repository.
+func isRepositoryRoot(dir string) (bool, error) {
+	exists, existsErr := glFS.FileExists(filepath.Join(dir, git.GitDirName))
+	if existsErr != nil {
+		return false, fmt.Errorf("failed to check if %q is a repository root directory: %w", dir, existsErr)
+	}
+	if exists {
+		return true, nil
+	}
+
+	for _, entry := range bareRepositoryEntries {
+		if exists, _ = glFS.FileExists(filepath.Join(dir, entry)); !exists {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/svengreb/golib/pkg/vcs/git/conventional"
+)
+
+// Scheme is a version scheme that defines which tag names are versions and how the next version is computed.
+// Versions of all schemes are represented as SemVer versions with the numeric version segments mapped to the major,
+// minor and patch version so that they can be compared and extended with prerelease versions and build metadata.
+type Scheme interface {
+	// Format returns the given version in the format of the scheme, including the prerelease version and build metadata.
+	Format(version *semver.Version) string
+
+	// Next computes the next version after the given current version for the given version bump at the given time.
+	// The current version can be nil when there is no previous version.
+	Next(current *semver.Version, bump conventional.Bump, now time.Time) (*semver.Version, error)
+
+	// Parse parses the given version, e.g. the name of a tag without the tag prefix, and returns an error when it is not
+	// valid for the scheme.
+	Parse(version string) (*semver.Version, error)
+}
+
+// SemVerScheme is the Scheme for "Semantic Versioning" that is used by default.
+// The next version is computed by incrementing the major, minor or patch version depending on the version bump.
+//
+// See https://semver.org for more details.
+type SemVerScheme struct{}
+
+// Format returns the given version in the canonical SemVer format.
+func (SemVerScheme) Format(version *semver.Version) string {
+	return version.String()
+}
+
+// Next increments the major, minor or patch version of the given current version depending on the given version bump,
+// or returns the current version when no version bump is implied.
+// A nil current version is treated as "0.0.0" while the given time is ignored.
+func (SemVerScheme) Next(current *semver.Version, bump conventional.Bump, _ time.Time) (*semver.Version, error) {
+	if current == nil {
+		current = semver.MustParse("0.0.0")
+	}
+
+	var next semver.Version
+	switch bump {
+	case conventional.BumpMajor:
+		next = current.IncMajor()
+	case conventional.BumpMinor:
+		next = current.IncMinor()
+	case conventional.BumpPatch:
+		next = current.IncPatch()
+	default:
+		next = *current
+	}
+
+	return &next, nil
+}
+
+// Parse parses the given version as SemVer version.
+// Like the "github.com/Masterminds/semver/v3" module, a "v" prefix as well as missing minor and patch versions are
+// accepted.
+func (SemVerScheme) Parse(version string) (*semver.Version, error) {
+	semVersion, semVerErr := semver.NewVersion(version)
+	if semVerErr != nil {
+		return nil, newError(ErrInvalidVersion, semVerErr, "failed to parse SemVer version %q", version)
+	}
+
+	return semVersion, nil
+}
+
+// versionScheme returns the configured version scheme or the SemVerScheme when none is set.
+func (o *Options) versionScheme() Scheme {
+	if o.Scheme == nil {
+		return SemVerScheme{}
+	}
+
+	return o.Scheme
+}
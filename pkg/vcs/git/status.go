@@ -0,0 +1,274 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// WorktreeStatus is the status of the index and worktree of a repository.
+type WorktreeStatus struct {
+	// Conflicted are the paths of files with unresolved merge conflicts, ordered by path.
+	// These files are not part of the staged and unstaged changes.
+	Conflicted []string
+
+	// Staged are the changes of files between HEAD and the index, ordered by path.
+	// These are the changes that would be committed, like shown by the Git "diff --cached" command.
+	Staged []*FileChange
+
+	// Unstaged are the changes of tracked files between the index and the worktree, ordered by path.
+	// These are the changes that are not staged for commit, like shown by the Git "diff" command.
+	Unstaged []*FileChange
+
+	// Untracked are the paths of files that are neither tracked nor ignored, ordered by path.
+	Untracked []string
+}
+
+// IsClean checks if there are neither conflicted, staged, unstaged nor untracked files.
+func (s *WorktreeStatus) IsClean() bool {
+	return len(s.Conflicted) == 0 && len(s.Staged) == 0 && len(s.Unstaged) == 0 && len(s.Untracked) == 0
+}
+
+// blobOverlay is an object storer that provides blobs of files in the worktree on top of the objects of a repository.
+// This allows to compare files in the worktree with the patch of tree changes without writing them into the
+// repository.
+type blobOverlay struct {
+	storer.EncodedObjectStorer
+	blobs map[plumbing.Hash]plumbing.EncodedObject
+}
+
+// QueryStatus queries the status of the index and worktree of a Git repository.
+// The status of bare repositories, which have no worktree, is always clean.
+// The DetectDotGit and CeilingDirs options are used to find the repository while all other options are ignored.
+func QueryStatus(repositoryPath string, opts ...Option) (*WorktreeStatus, error) {
+	repo, repoOpenErr := openRepository(repositoryPath, NewOptions(opts...))
+	if repoOpenErr != nil {
+		return nil, repoOpenErr
+	}
+
+	return QueryStatusFromRepository(repo)
+}
+
+// QueryStatusFromRepository is like QueryStatus but queries the status of the given already opened repository.
+func QueryStatusFromRepository(repo *git.Repository) (*WorktreeStatus, error) {
+	if repo == nil {
+		return nil, newError(ErrRepositoryNotFound, nil, "repository must not be nil")
+	}
+	wt, wtErr := repo.Worktree()
+	if wtErr != nil {
+		if errors.Is(wtErr, git.ErrIsBareRepository) {
+			return &WorktreeStatus{}, nil
+		}
+		return nil, fmt.Errorf("failed to get worktree: %w", wtErr)
+	}
+
+	idx, idxErr := repo.Storer.Index()
+	if idxErr != nil {
+		return nil, fmt.Errorf("failed to read index: %w", idxErr)
+	}
+	result := &WorktreeStatus{}
+	conflicted := make(map[string]bool)
+	entries := make(map[string]*index.Entry, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		if entry.Stage != 0 {
+			if !conflicted[entry.Name] {
+				result.Conflicted = append(result.Conflicted, entry.Name)
+			}
+			conflicted[entry.Name] = true
+			continue
+		}
+		entries[entry.Name] = entry
+	}
+
+	headTree, headTreeErr := resolveHeadTree(repo)
+	if headTreeErr != nil {
+		return nil, headTreeErr
+	}
+	status, statusErr := wt.Status()
+	if statusErr != nil {
+		return nil, fmt.Errorf("failed to get worktree status: %w", statusErr)
+	}
+
+	overlay, tree, overlayErr := newBlobOverlay(repo.Storer)
+	if overlayErr != nil {
+		return nil, overlayErr
+	}
+	changeEntry := func(name string, mode filemode.FileMode, hash plumbing.Hash) object.ChangeEntry {
+		return object.ChangeEntry{
+			Name:      name,
+			Tree:      tree,
+			TreeEntry: object.TreeEntry{Name: path.Base(name), Mode: mode, Hash: hash},
+		}
+	}
+
+	var staged, unstaged object.Changes
+	for name, fileStatus := range status {
+		if conflicted[name] {
+			continue
+		}
+		if fileStatus.Worktree == git.Untracked {
+			result.Untracked = append(result.Untracked, name)
+		}
+
+		if fileStatus.Staging != git.Unmodified && fileStatus.Staging != git.Untracked {
+			change := &object.Change{}
+			if headTree != nil {
+				headEntry, findErr := headTree.FindEntry(name)
+				switch {
+				case findErr == nil:
+					change.From = changeEntry(name, headEntry.Mode, headEntry.Hash)
+				case !errors.Is(findErr, object.ErrEntryNotFound) && !errors.Is(findErr, object.ErrDirectoryNotFound):
+					return nil, fmt.Errorf("failed to find %q in tree of HEAD: %w", name, findErr)
+				}
+			}
+			if entry, ok := entries[name]; ok {
+				change.To = changeEntry(name, entry.Mode, entry.Hash)
+			}
+			if change.From != (object.ChangeEntry{}) || change.To != (object.ChangeEntry{}) {
+				staged = append(staged, change)
+			}
+		}
+
+		if fileStatus.Worktree != git.Unmodified && fileStatus.Worktree != git.Untracked {
+			change := &object.Change{}
+			if entry, ok := entries[name]; ok {
+				change.From = changeEntry(name, entry.Mode, entry.Hash)
+			}
+			if fileStatus.Worktree != git.Deleted {
+				mode, hash, addErr := overlay.addFile(wt.Filesystem, name)
+				if addErr != nil {
+					return nil, addErr
+				}
+				change.To = changeEntry(name, mode, hash)
+			}
+			if change.From != (object.ChangeEntry{}) || change.To != (object.ChangeEntry{}) {
+				unstaged = append(unstaged, change)
+			}
+		}
+	}
+	sort.Strings(result.Conflicted)
+	sort.Strings(result.Untracked)
+
+	var changesErr error
+	if result.Staged, changesErr = newStatusFileChanges(staged); changesErr != nil {
+		return nil, fmt.Errorf("failed to compare HEAD with index: %w", changesErr)
+	}
+	if result.Unstaged, changesErr = newStatusFileChanges(unstaged); changesErr != nil {
+		return nil, fmt.Errorf("failed to compare index with worktree: %w", changesErr)
+	}
+
+	return result, nil
+}
+
+// newStatusFileChanges creates the file changes of the given changes after renamed files have been detected.
+func newStatusFileChanges(changes object.Changes) ([]*FileChange, error) {
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	renamed, renameErr := object.DetectRenames(changes, object.DefaultDiffTreeOptions)
+	if renameErr != nil {
+		return nil, fmt.Errorf("failed to detect renamed files: %w", renameErr)
+	}
+
+	return newFileChanges(renamed)
+}
+
+// resolveHeadTree returns the tree of the commit HEAD is pointing to, or nil when HEAD is unborn.
+func resolveHeadTree(repo *git.Repository) (*object.Tree, error) {
+	head, headErr := repo.Head()
+	switch {
+	case errors.Is(headErr, plumbing.ErrReferenceNotFound):
+		return nil, nil
+	case headErr != nil:
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", headErr)
+	}
+	commit, commitErr := repo.CommitObject(head.Hash())
+	if commitErr != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", head.Hash(), commitErr)
+	}
+	tree, treeErr := commit.Tree()
+	if treeErr != nil {
+		return nil, fmt.Errorf("failed to get tree of commit %s: %w", head.Hash(), treeErr)
+	}
+
+	return tree, nil
+}
+
+// newBlobOverlay creates a new blob overlay on top of the given object storer together with an empty tree that is
+// associated with the overlay so that files of changes can be resolved through it.
+func newBlobOverlay(s storer.EncodedObjectStorer) (*blobOverlay, *object.Tree, error) {
+	overlay := &blobOverlay{EncodedObjectStorer: s, blobs: make(map[plumbing.Hash]plumbing.EncodedObject)}
+	emptyTree := &plumbing.MemoryObject{}
+	emptyTree.SetType(plumbing.TreeObject)
+	tree, treeErr := object.DecodeTree(overlay, emptyTree)
+	if treeErr != nil {
+		return nil, nil, fmt.Errorf("failed to create tree: %w", treeErr)
+	}
+
+	return overlay, tree, nil
+}
+
+// EncodedObject returns the blob of a file in the worktree, or otherwise the object of the underlying storer.
+func (o *blobOverlay) EncodedObject(t plumbing.ObjectType, h plumbing.Hash) (plumbing.EncodedObject, error) {
+	if obj, ok := o.blobs[h]; ok && (t == plumbing.AnyObject || t == plumbing.BlobObject) {
+		return obj, nil
+	}
+
+	return o.EncodedObjectStorer.EncodedObject(t, h)
+}
+
+// addFile adds the blob of the file with the given name in the given worktree filesystem and returns its mode and hash.
+// Like Git, the blob of a symbolic link is the path it is pointing to while directories, which are submodules, have
+// no blob.
+func (o *blobOverlay) addFile(fs billy.Filesystem, name string) (filemode.FileMode, plumbing.Hash, error) {
+	info, statErr := fs.Lstat(name)
+	if statErr != nil {
+		return filemode.Empty, plumbing.ZeroHash, fmt.Errorf("failed to get file info of %q: %w", name, statErr)
+	}
+	if info.IsDir() {
+		return filemode.Submodule, plumbing.ZeroHash, nil
+	}
+	mode, modeErr := filemode.NewFromOSFileMode(info.Mode())
+	if modeErr != nil {
+		return filemode.Empty, plumbing.ZeroHash, fmt.Errorf("unsupported file mode of %q: %w", name, modeErr)
+	}
+
+	obj := &plumbing.MemoryObject{}
+	obj.SetType(plumbing.BlobObject)
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, linkErr := fs.Readlink(name)
+		if linkErr != nil {
+			return filemode.Empty, plumbing.ZeroHash, fmt.Errorf("failed to read symbolic link %q: %w", name, linkErr)
+		}
+		if _, writeErr := obj.Write([]byte(target)); writeErr != nil {
+			return filemode.Empty, plumbing.ZeroHash, writeErr
+		}
+	} else {
+		f, openErr := fs.Open(name)
+		if openErr != nil {
+			return filemode.Empty, plumbing.ZeroHash, fmt.Errorf("failed to open file %q: %w", name, openErr)
+		}
+		_, copyErr := io.Copy(obj, f)
+		_ = f.Close()
+		if copyErr != nil {
+			return filemode.Empty, plumbing.ZeroHash, fmt.Errorf("failed to read file %q: %w", name, copyErr)
+		}
+	}
+	o.blobs[obj.Hash()] = obj
+
+	return mode, obj.Hash(), nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+// writeFile writes the file with the given name and content into the worktree.
+func (tr *testRepository) writeFile(name, content string) {
+	tr.t.Helper()
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(tr.path, name)), 0o700); err != nil {
+		assert.FailNow(tr.t, "failed to create directory", "file: %q\nerror: %v", name, err)
+	}
+	if err := os.WriteFile(filepath.Join(tr.path, name), []byte(content), 0o600); err != nil {
+		assert.FailNow(tr.t, "failed to write file", "file: %q\nerror: %v", name, err)
+	}
+}
+
+// worktree returns the worktree of the repository.
+func (tr *testRepository) worktree() *git.Worktree {
+	tr.t.Helper()
+	wt, err := tr.repo.Worktree()
+	if err != nil {
+		assert.FailNow(tr.t, "failed to get worktree", "error: %v", err)
+	}
+
+	return wt
+}
+
+// stageFile writes the file with the given name and content into the worktree and adds it to the index.
+func (tr *testRepository) stageFile(name, content string) {
+	tr.t.Helper()
+	tr.writeFile(name, content)
+	if _, err := tr.worktree().Add(name); err != nil {
+		assert.FailNow(tr.t, "failed to add file", "file: %q\nerror: %v", name, err)
+	}
+}
+
+// commitStaged commits the staged changes with the given message.
+func (tr *testRepository) commitStaged(msg string) plumbing.Hash {
+	tr.t.Helper()
+	tr.commits++
+	hash, err := tr.worktree().Commit(msg, &git.CommitOptions{Author: tr.signature()})
+	if err != nil {
+		assert.FailNow(tr.t, "failed to commit", "message: %q\nerror: %v", msg, err)
+	}
+
+	return hash
+}
+
+func TestQueryStatus(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.stageFile("README.md", "# golib\n")
+	tr.stageFile("main.go", "package main\n")
+	tr.stageFile("docs/guide.md", strings.Repeat("guide\n", 10))
+	tr.commitStaged("first")
+
+	status, err := glGit.QueryStatus(tr.path)
+	assert.NoError(t, err)
+	assert.True(t, status.IsClean())
+
+	tr.stageFile("new.go", "package new\n")
+	if _, err = tr.worktree().Move("docs/guide.md", "docs/manual.md"); err != nil {
+		assert.FailNow(t, "failed to move file", "error: %v", err)
+	}
+	tr.writeFile("new.go", "package new\n\nfunc f() {}\n")
+	tr.writeFile("README.md", "# golib\n\nA library.\n")
+	if err = os.Remove(filepath.Join(tr.path, "main.go")); err != nil {
+		assert.FailNow(t, "failed to remove file", "error: %v", err)
+	}
+	tr.writeFile(".gitignore", "*.log\n")
+	tr.writeFile("debug.log", "ignored")
+	tr.writeFile("untracked.txt", "untracked")
+
+	status, err = glGit.QueryStatus(tr.path)
+	assert.NoError(t, err)
+	assert.False(t, status.IsClean())
+	assert.Empty(t, status.Conflicted)
+	assert.Equal(t, []*glGit.FileChange{
+		{Kind: glGit.ChangeRenamed, From: "docs/guide.md", To: "docs/manual.md"},
+		{Kind: glGit.ChangeAdded, To: "new.go", AddedLines: 1},
+	}, status.Staged)
+	assert.Equal(t, []*glGit.FileChange{
+		{Kind: glGit.ChangeModified, From: "README.md", To: "README.md", AddedLines: 2},
+		{Kind: glGit.ChangeDeleted, From: "main.go", RemovedLines: 1},
+		{Kind: glGit.ChangeModified, From: "new.go", To: "new.go", AddedLines: 2},
+	}, status.Unstaged)
+	assert.Equal(t, []string{".gitignore", "untracked.txt"}, status.Untracked)
+}
+
+func TestQueryStatus_Conflicted(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.stageFile("README.md", "# golib\n")
+	tr.stageFile("conflict.txt", "base\n")
+	tr.commitStaged("first")
+
+	idx, err := tr.repo.Storer.Index()
+	if err != nil {
+		assert.FailNow(t, "failed to read index", "error: %v", err)
+	}
+	entry, err := idx.Entry("conflict.txt")
+	if err != nil {
+		assert.FailNow(t, "failed to get index entry", "error: %v", err)
+	}
+	for _, stage := range []index.Stage{index.OurMode, index.TheirMode} {
+		idx.Entries = append(idx.Entries, &index.Entry{Name: entry.Name, Hash: entry.Hash, Mode: entry.Mode, Stage: stage})
+	}
+	if err = tr.repo.Storer.SetIndex(idx); err != nil {
+		assert.FailNow(t, "failed to write index", "error: %v", err)
+	}
+	tr.writeFile("conflict.txt", "<<<<<<< ours\n=======\n>>>>>>> theirs\n")
+
+	status, err := glGit.QueryStatus(tr.path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"conflict.txt"}, status.Conflicted)
+	assert.Empty(t, status.Staged)
+	assert.Empty(t, status.Unstaged)
+	assert.False(t, status.IsClean())
+}
+
+func TestQueryStatus_UnbornHead(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.stageFile("README.md", "# golib\n")
+	tr.stageFile("logo.png", "\x89PNG\x00")
+
+	status, err := glGit.QueryStatus(tr.path)
+	assert.NoError(t, err)
+	assert.Equal(t, []*glGit.FileChange{
+		{Kind: glGit.ChangeAdded, To: "README.md", AddedLines: 1},
+		{Kind: glGit.ChangeAdded, To: "logo.png", Binary: true},
+	}, status.Staged)
+	assert.Empty(t, status.Unstaged)
+
+	bare, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		assert.FailNow(t, "failed to initialize bare in-memory repository", "error: %v", err)
+	}
+	status, err = glGit.QueryStatusFromRepository(bare)
+	assert.NoError(t, err)
+	assert.True(t, status.IsClean())
+}
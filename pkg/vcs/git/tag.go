@@ -0,0 +1,169 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// TagOptions are options to create an annotated version tag.
+type TagOptions struct {
+	// Message is the message of the tag.
+	// The name of the tag is used when empty.
+	Message string
+
+	// SignKey is the OpenPGP key to sign the tag with.
+	// The private key must be present and already decrypted. The tag is not signed when nil.
+	SignKey *openpgp.Entity
+
+	// Tagger is the signature of the tag creator.
+	// The name and email are read from the Git configuration and the current time is used when nil.
+	Tagger *object.Signature
+}
+
+// CreateVersionTag creates a SemVer (https://semver.org) compatible version tag for the given revision of a Git
+// repository and returns the reference of the new tag.
+// The name of the tag is the canonical form of the given version in the version scheme with the tag prefix set through
+// the TagPrefix or ModuleDir option, e.g. "v1.2.0" for the version "1.2" with the SemVerScheme where the "v" prefix
+// is omitted when the tag prefix already ends with it. The revision can be any revision supported by the Git
+// "rev-parse" command, like a branch, tag or commit hash, and defaults to HEAD when empty.
+//
+// A lightweight tag is created when the given tag options are nil, otherwise an annotated tag that is signed when a
+// sign key is set. This is the equivalent of the "git tag", "git tag -a" and "git tag -s" commands.
+//
+// To ensure that versions are only ever increasing, an error wrapping ErrTagVersionNotIncreasing is returned when the
+// version is not higher than the version of the latest existing version tag. All version tags of the repository are
+// taken into account, including lightweight tags and tags that are not reachable from the revision, that are
+// considered by the TagPrefix, ModuleDir, MatchPatterns and ExcludePatterns options. The DetectDotGit and CeilingDirs
+// options are used to find the repository while all other options are ignored.
+func CreateVersionTag(
+	repositoryPath, version, revision string,
+	tagOpts *TagOptions,
+	opts ...Option,
+) (*plumbing.Reference, error) {
+	opt := NewOptions(opts...)
+
+	repo, repoOpenErr := openRepository(repositoryPath, opt)
+	if repoOpenErr != nil {
+		return nil, repoOpenErr
+	}
+
+	return createVersionTag(repo, version, revision, tagOpts, opt)
+}
+
+// CreateVersionTagFromRepository is like CreateVersionTag but creates the version tag in the given already opened
+// repository.
+func CreateVersionTagFromRepository(
+	repo *git.Repository,
+	version, revision string,
+	tagOpts *TagOptions,
+	opts ...Option,
+) (*plumbing.Reference, error) {
+	if repo == nil {
+		return nil, newError(ErrRepositoryNotFound, nil, "repository must not be nil")
+	}
+
+	return createVersionTag(repo, version, revision, tagOpts, NewOptions(opts...))
+}
+
+// createVersionTag creates a version tag for the given revision of the given repository.
+func createVersionTag(
+	repo *git.Repository,
+	version, revision string,
+	tagOpts *TagOptions,
+	opt *Options,
+) (*plumbing.Reference, error) {
+	semVersion, semVerErr := opt.versionScheme().Parse(version)
+	if semVerErr != nil {
+		return nil, semVerErr
+	}
+
+	if revision == "" {
+		revision = plumbing.HEAD.String()
+	}
+	hash, resolveErr := repo.ResolveRevision(plumbing.Revision(revision))
+	if resolveErr != nil {
+		return nil, newError(ErrRevisionNotFound, resolveErr, "failed to resolve revision %q", revision)
+	}
+
+	tagPrefix, prefixErr := resolveTagPrefix(repo, opt)
+	if prefixErr != nil {
+		return nil, prefixErr
+	}
+	latest, latestErr := latestTagVersion(repo, tagPrefix, opt)
+	if latestErr != nil {
+		return nil, latestErr
+	}
+	if latest != nil && !semVersion.GreaterThan(latest.version) {
+		return nil, newError(ErrTagVersionNotIncreasing, nil, "%s is not higher than %s of tag %s",
+			semVersion, latest.version, latest.ref.Name().Short())
+	}
+
+	name := tagPrefix + tagVersion(semVersion, tagPrefix, opt.versionScheme())
+	var createOpts *git.CreateTagOptions
+	if tagOpts != nil {
+		createOpts = &git.CreateTagOptions{Message: tagOpts.Message, SignKey: tagOpts.SignKey, Tagger: tagOpts.Tagger}
+		if strings.TrimSpace(createOpts.Message) == "" {
+			createOpts.Message = name
+		}
+	}
+	ref, createErr := repo.CreateTag(name, *hash, createOpts)
+	if createErr != nil {
+		return nil, fmt.Errorf("failed to create tag %s: %w", name, createErr)
+	}
+
+	return ref, nil
+}
+
+// tagVersion returns the given version in the canonical format of the given scheme for a tag name with the given
+// prefix.
+// Like the Go toolchain, SemVer versions are prefixed with "v" unless the tag prefix already ends with it.
+func tagVersion(version *semver.Version, tagPrefix string, scheme Scheme) string {
+	formatted := scheme.Format(version)
+	switch scheme.(type) {
+	case SemVerScheme, *SemVerScheme:
+		if !strings.HasSuffix(tagPrefix, "v") {
+			formatted = "v" + formatted
+		}
+	}
+
+	return formatted
+}
+
+// latestTagVersion returns the tag with the highest version of all version tags with the given prefix, or nil if there
+// is none.
+func latestTagVersion(repo *git.Repository, prefix string, opt *Options) (*tagCandidate, error) {
+	tagIterator, repoTagsErr := repo.Tags()
+	if repoTagsErr != nil {
+		return nil, fmt.Errorf("failed to get all tag references: %w", repoTagsErr)
+	}
+	defer tagIterator.Close()
+
+	var latest *tagCandidate
+	if tagIterErr := tagIterator.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if !matchesTagName(name, opt) || !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		semVersion, semVerParseErr := opt.versionScheme().Parse(strings.TrimPrefix(name, prefix))
+		if semVerParseErr != nil {
+			return nil
+		}
+		if latest == nil || semVersion.GreaterThan(latest.version) {
+			latest = &tagCandidate{ref: ref, version: semVersion}
+		}
+		return nil
+	}); tagIterErr != nil {
+		return nil, fmt.Errorf("failed to iterate over tags: %w", tagIterErr)
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+// newTestEntity creates an OpenPGP entity with a private key for testing purposes.
+func newTestEntity(t *testing.T) *openpgp.Entity {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Sven Greb", "", "development@svengreb.de",
+		&packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		assert.FailNow(t, "failed to create OpenPGP entity", "error: %v", err)
+	}
+
+	return entity
+}
+
+// armoredKeyRing returns an armored key ring with the public keys of the given entities.
+func armoredKeyRing(t *testing.T, entities ...*openpgp.Entity) string {
+	t.Helper()
+	var keyRing bytes.Buffer
+	w, err := armor.Encode(&keyRing, openpgp.PublicKeyType, nil)
+	if err != nil {
+		assert.FailNow(t, "failed to encode armored key ring", "error: %v", err)
+	}
+	for _, entity := range entities {
+		if err = entity.Serialize(w); err != nil {
+			assert.FailNow(t, "failed to serialize OpenPGP entity", "error: %v", err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		assert.FailNow(t, "failed to encode armored key ring", "error: %v", err)
+	}
+
+	return keyRing.String()
+}
+
+func TestCreateVersionTag(t *testing.T) {
+	tr := newTestRepository(t)
+	first := tr.commit("feat: initial feature")
+	tr.tag("v1.0.0", first, "")
+	second := tr.commit("fix: fix bug")
+
+	ref, err := glGit.CreateVersionTag(tr.path, "v1.0.1", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.1", ref.Name().Short())
+	assert.Equal(t, second, ref.Hash())
+
+	_, err = glGit.CreateVersionTag(tr.path, "v1.0.1", first.String(), nil)
+	assert.True(t, errors.Is(err, glGit.ErrTagVersionNotIncreasing))
+	_, err = glGit.CreateVersionTag(tr.path, "v0.9.0", "", nil)
+	assert.True(t, errors.Is(err, glGit.ErrTagVersionNotIncreasing))
+	_, err = glGit.CreateVersionTag(tr.path, "invalid", "", nil)
+	assert.Error(t, err)
+
+	ref, err = glGit.CreateVersionTag(tr.path, "0.1.0", "", &glGit.TagOptions{Tagger: tr.signature()},
+		glGit.WithTagPrefix("sub/v"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sub/v0.1.0", ref.Name().Short())
+	tagObject, err := tr.repo.TagObject(ref.Hash())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "sub/v0.1.0\n", tagObject.Message)
+		assert.Equal(t, second, tagObject.Target)
+	}
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithTags(true))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.1", version.String())
+}
+
+func TestCreateVersionTag_CanonicalName(t *testing.T) {
+	tr := newTestRepository(t)
+	head := tr.commit("feat: initial feature")
+
+	for _, tc := range []struct {
+		expected string
+		opts     []glGit.Option
+		version  string
+	}{
+		{expected: "v1.2.0", version: "1.2"},
+		{expected: "v1.3.0-rc.1", version: "v1.3-rc.1"},
+		{expected: "sub/v0.1.0", opts: []glGit.Option{glGit.WithTagPrefix("sub/v")}, version: "v0.1"},
+		{expected: "mod/v0.2.0", opts: []glGit.Option{glGit.WithTagPrefix("mod/")}, version: "0.2.0"},
+	} {
+		ref, err := glGit.CreateVersionTagFromRepository(tr.repo, tc.version, "", nil, tc.opts...)
+		if assert.NoError(t, err, "version: %q", tc.version) {
+			assert.Equal(t, tc.expected, ref.Name().Short())
+			assert.Equal(t, head, ref.Hash())
+		}
+	}
+
+	// Tags are compared by their version regardless of the format of the given version.
+	_, err := glGit.CreateVersionTagFromRepository(tr.repo, "1.3.0-rc.1", "", nil)
+	assert.True(t, errors.Is(err, glGit.ErrTagVersionNotIncreasing))
+	_, err = glGit.CreateVersionTagFromRepository(tr.repo, "v0.1.0", "", nil, glGit.WithTagPrefix("sub/v"))
+	assert.True(t, errors.Is(err, glGit.ErrTagVersionNotIncreasing))
+
+	_, err = glGit.CreateVersionTagFromRepository(nil, "1.0.0", "", nil)
+	assert.True(t, errors.Is(err, glGit.ErrRepositoryNotFound))
+}
+
+func TestCreateVersionTag_Signed(t *testing.T) {
+	tr := newTestRepository(t)
+	head := tr.commit("feat: initial feature")
+	entity := newTestEntity(t)
+
+	ref, err := glGit.CreateVersionTag(tr.path, "v1.0.0", "", &glGit.TagOptions{
+		Message: "Release v1.0.0",
+		SignKey: entity,
+		Tagger:  tr.signature(),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	tagObject, err := tr.repo.TagObject(ref.Hash())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, head, tagObject.Target)
+	assert.Equal(t, "Release v1.0.0\n", tagObject.Message)
+	assert.NotEmpty(t, tagObject.PGPSignature)
+
+	signer, err := tagObject.Verify(armoredKeyRing(t, entity))
+	assert.NoError(t, err)
+	assert.Equal(t, entity.PrimaryKey.KeyId, signer.PrimaryKey.KeyId)
+}
@@ -13,15 +13,16 @@
 package git
 
 import (
-	"bytes"
+	"context"
 	"fmt"
+	"path"
 	"strconv"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/storer"
 )
 
 const (
@@ -37,170 +38,347 @@ type Version struct {
 	// See https://semver.org for more details.
 	*semver.Version
 
-	// CommitsAhead is the amount of commits ahead to the latest Git version tag in the current branch.
+	// Branch is the short name of the branch HEAD is pointing to, or the Branch option when HEAD is detached, which is
+	// empty when not set.
+	Branch string
+
+	// BranchTip indicates whether HEAD is pointing to the tip of a local or remote-tracking branch.
+	// This is always the case when HEAD is not detached.
+	BranchTip bool
+
+	// Channel is the prerelease channel the version has been derived for, or empty if the version is not a prerelease
+	// version of a channel.
+	Channel string
+
+	// CommitsAhead is the amount of commits ahead to the latest Git version tag in the history of HEAD.
 	CommitsAhead int
 
-	// CommitHash is the hash of the latest commit in the current branch.
+	// CommitHash is the hash of the commit HEAD is pointing to.
 	CommitHash plumbing.Hash
 
-	// LatestVersionTag is the latest Git version tag in the current branch.
+	// Detached indicates whether HEAD is detached, i.e. pointing directly to a commit instead of a branch.
+	Detached bool
+
+	// Dirty indicates whether the worktree has uncommitted changes.
+	// Note that this is only detected when explicitly enabled through the options.
+	Dirty bool
+
+	// HeadRef is the name of the reference HEAD is symbolically pointing to, or "HEAD" when detached.
+	HeadRef plumbing.ReferenceName
+
+	// LatestVersionTag is the latest Git version tag in the history of HEAD.
 	LatestVersionTag *plumbing.Reference
+
+	// Signer is the OpenPGP entity whose key signed the latest Git version tag.
+	// Note that this is only set when the verification of tag signatures is enabled through the options.
+	Signer *openpgp.Entity
+
+	// scheme is the version scheme the version has been derived with.
+	scheme Scheme
+
+	// tagVersion is the version parsed from the latest Git version tag without appended build metadata.
+	tagVersion *semver.Version
 }
 
 // DeriveVersion derives version information and metadata from a Git repository.
-// It searches for the latest SemVer (https://semver.org) compatible version tag in the current branch and falls
-// back to the given default version if no tag is found.
-// If at least one tag is found, but it is not the latest commit of the current branch, the build metadata is appended,
-// consisting of the amount of commits ahead and the shortened reference hash (8 digits) of the latest commit from the
-// current branch.
+// It searches for the latest SemVer (https://semver.org) compatible version tag, or a version tag of the version scheme
+// set through the Scheme option, in the history of HEAD and falls back to the given default version if no tag is
+// found.
+// If at least one tag is found, but it is not the commit HEAD is pointing to, the build metadata is appended,
+// consisting of the amount of commits ahead and the shortened reference hash (8 digits by default) of the commit HEAD
+// is pointing to.
+// The derivation always starts from HEAD itself, so the same version is derived regardless of whether HEAD is
+// pointing to a branch or is detached.
+// The search and the format of the build metadata can be customized through the given options that are modelled after
+// the flags of the Git "describe" command.
+//
+// Errors can be inspected through the errors.Is function for the failure class, like ErrRepositoryNotFound,
+// ErrEmptyRepository or ErrUnbornHead, and through the errors.As function for an *Error or a CanceledError.
 //
 // This function is an early implementation of the Git "describe" command because support in the
 // "github.com/go-git/go-git/v5" module has not been implemented yet. See the full compatibility comparison
 // documentation with Git at https://github.com/go-git/go-git/blob/master/COMPATIBILITY.md as well as the proposed Git
 // "describe" command implementation at https://github.com/src-d/go-git/pull/816 for more details.
-func DeriveVersion(defaultVersion, repositoryPath string) (*Version, error) {
-	if defaultVersion == "" {
-		return nil, fmt.Errorf("default version must not be empty")
+func DeriveVersion(defaultVersion, repositoryPath string, opts ...Option) (*Version, error) {
+	return DeriveVersionContext(context.Background(), defaultVersion, repositoryPath, opts...)
+}
+
+// DeriveVersionContext is like DeriveVersion but honors the cancellation and deadline of the given context while
+// scanning the tags and traversing the commit history.
+// When the context is done before the derivation has been completed, a CanceledError is returned that reports how far
+// the traversal got and wraps the error of the context.
+func DeriveVersionContext(ctx context.Context, defaultVersion, repositoryPath string, opts ...Option) (*Version, error) {
+	opt := NewOptions(opts...)
+	if validateErr := validateDerivation(defaultVersion, opt); validateErr != nil {
+		return nil, validateErr
 	}
 
-	repo, repoOpenErr := git.PlainOpen(repositoryPath)
+	repo, repoOpenErr := openRepository(repositoryPath, opt)
 	if repoOpenErr != nil {
-		return nil, fmt.Errorf("failed to open repository at path %q: %v", repositoryPath, repoOpenErr)
+		return nil, repoOpenErr
 	}
 
-	// Find the latest commit reference of the current branch.
-	branchRefs, repoBranchErr := repo.Branches()
-	if repoBranchErr != nil {
-		return nil, fmt.Errorf("failed to find latest commit reference of the current branch: %v", repoBranchErr)
+	return deriveVersion(ctx, repo, defaultVersion, opt)
+}
+
+// DeriveVersionFromRepository is like DeriveVersion but derives the version from the given already opened repository,
+// e.g. a repository that is backed by an in-memory storage and worktree filesystem.
+// When the worktree is not backed by the filesystem of the operating system, the path of the ModuleDir option is
+// relative to the root of the worktree.
+func DeriveVersionFromRepository(repo *git.Repository, defaultVersion string, opts ...Option) (*Version, error) {
+	return DeriveVersionFromRepositoryContext(context.Background(), repo, defaultVersion, opts...)
+}
+
+// DeriveVersionFromRepositoryContext is like DeriveVersionFromRepository but honors the cancellation and deadline of
+// the given context like DeriveVersionContext.
+func DeriveVersionFromRepositoryContext(
+	ctx context.Context,
+	repo *git.Repository,
+	defaultVersion string,
+	opts ...Option,
+) (*Version, error) {
+	if repo == nil {
+		return nil, newError(ErrRepositoryNotFound, nil, "repository must not be nil")
 	}
-	headRef, repoHeadErr := repo.Head()
-	if repoHeadErr != nil {
-		return nil, fmt.Errorf("failed to get the reference where HEAD is pointing to: %v", repoHeadErr)
+	opt := NewOptions(opts...)
+	if validateErr := validateDerivation(defaultVersion, opt); validateErr != nil {
+		return nil, validateErr
 	}
-	var currentBranchRef plumbing.Reference
-	branchRefIterErr := branchRefs.ForEach(func(branchRef *plumbing.Reference) error {
-		if branchRef.Hash() == headRef.Hash() {
-			currentBranchRef = *branchRef
-			return nil
-		}
-		return nil
-	})
-	if branchRefIterErr != nil {
-		return nil, fmt.Errorf("failed to iterate over references: %v", branchRefIterErr)
-	}
-
-	// Find all commits in the repository starting from HEAD of the current branch.
-	commitIterator, commitIterErr := repo.Log(&git.LogOptions{
-		From:  currentBranchRef.Hash(),
-		Order: git.LogOrderCommitterTime,
-	})
-	if commitIterErr != nil {
-		return nil, fmt.Errorf("failed to get the commit history from the current branch: %v", commitIterErr)
-	}
-
-	// Query all tags and store them in a temporary map.
-	tagIterator, repoTagsErr := repo.Tags()
-	if repoTagsErr != nil {
-		return nil, fmt.Errorf("failed to get all tag references: %v", repoTagsErr)
-	}
-	tags := make(map[plumbing.Hash]*plumbing.Reference)
-	tagIterErr := tagIterator.ForEach(func(tag *plumbing.Reference) error {
-		if tagObject, tagObjectErr := repo.TagObject(tag.Hash()); tagObjectErr == nil {
-			// Only include tags that have a valid SemVer version format.
-			if _, semVerParseErr := semver.NewVersion(tag.Name().Short()); semVerParseErr == nil {
-				tags[tagObject.Target] = tag
-			}
-		} else {
-			tags[tag.Hash()] = tag
+
+	return deriveVersion(ctx, repo, defaultVersion, opt)
+}
+
+// validateDerivation validates the given default version and options for a version derivation.
+func validateDerivation(defaultVersion string, opt *Options) error {
+	if defaultVersion == "" {
+		return newError(ErrInvalidDefaultVersion, nil, "default version must not be empty")
+	}
+	if _, semVerErr := opt.versionScheme().Parse(defaultVersion); semVerErr != nil {
+		return newError(ErrInvalidDefaultVersion, semVerErr, "failed to parse default version %q", defaultVersion)
+	}
+	if opt.Candidates < 0 {
+		return newError(ErrInvalidOption, nil, "amount of tag candidates must not be negative: %d", opt.Candidates)
+	}
+	if opt.TraversalMode < TraversalCommitterTime || opt.TraversalMode > TraversalGraph {
+		return newError(ErrInvalidOption, nil, "invalid traversal mode: %d", opt.TraversalMode)
+	}
+	for _, pattern := range append(opt.MatchPatterns, opt.ExcludePatterns...) {
+		if _, matchErr := path.Match(pattern, ""); matchErr != nil {
+			return newError(ErrInvalidOption, matchErr, "invalid tag name pattern %q", pattern)
 		}
-		return nil
-	})
-	tagIterator.Close()
-	if tagIterErr != nil {
-		return nil, fmt.Errorf("failed to iterate over tags: %v", tagIterErr)
-	}
-
-	type describeCandidate struct {
-		ref       *plumbing.Reference
-		annotated bool
-		distance  int
-	}
-	var tagCandidates []*describeCandidate
-	var tagCandidatesFound int
-	tagCount := -1
-
-	// Search for maximal 10 (Git default) suitable tag candidates in all commits of the current branch.
-	for {
-		candidate := &describeCandidate{annotated: false}
-		tagCommitIterErr := commitIterator.ForEach(func(commit *object.Commit) error {
-			tagCount++
-			if tagReference, ok := tags[commit.Hash]; ok {
-				delete(tags, commit.Hash)
-				candidate.ref = tagReference
-				hash := tagReference.Hash()
-				if !bytes.Equal(commit.Hash[:], hash[:]) {
-					candidate.annotated = true
-				}
-				return storer.ErrStop
-			}
-			return nil
-		})
-		if tagCommitIterErr != nil {
-			return nil, fmt.Errorf("failed to iterate over commits: %v", tagCommitIterErr)
+	}
+	if branchErr := validateBranchRules(opt); branchErr != nil {
+		return branchErr
+	}
+	if channelErr := validateChannels(opt); channelErr != nil {
+		return channelErr
+	}
+	if opt.VerifyKeyRing != "" {
+		if _, keyRingErr := openpgp.ReadArmoredKeyRing(strings.NewReader(opt.VerifyKeyRing)); keyRingErr != nil {
+			return newError(ErrInvalidOption, keyRingErr, "failed to read armored key ring to verify tag signatures")
 		}
+	}
 
-		if candidate.annotated {
-			if tagCandidatesFound < MaxSuitableTagCandidates {
-				candidate.distance = tagCount
-				tagCandidates = append(tagCandidates, candidate)
-			}
-			tagCandidatesFound++
+	return nil
+}
+
+// deriveVersion derives version information and metadata from the given repository.
+// The default version and options must have been validated before.
+func deriveVersion(ctx context.Context, repo *git.Repository, defaultVersion string, opt *Options) (*Version, error) {
+	p := newProgress(ctx)
+	if progressErr := p.check(); progressErr != nil {
+		return nil, progressErr
+	}
+
+	head, headErr := resolveHead(repo)
+	if headErr != nil {
+		return nil, headErr
+	}
+	if head.detached {
+		head.branch = opt.Branch
+	}
+
+	tagPrefix, tagPrefixErr := resolveTagPrefix(repo, opt)
+	if tagPrefixErr != nil {
+		return nil, tagPrefixErr
+	}
+
+	constraint := resolveBranchConstraint(head.branch, opt)
+	tags, tagsErr := collectTagCandidates(repo, tagPrefix, constraint, opt, p)
+	if tagsErr != nil {
+		return nil, tagsErr
+	}
+
+	index, closeIndex := openCommitNodeIndex(repo)
+	defer closeIndex()
+	candidate, describeErr := describe(index, head.hash, tags, opt, p)
+	if describeErr != nil {
+		return nil, describeErr
+	}
+	var pre *prerelease
+	if channel := resolveChannel(head, opt); channel != "" && (candidate == nil || candidate.distance > 0) {
+		var preErr error
+		if pre, preErr = newPrerelease(index, head.hash, candidate, channel, opt, p); preErr != nil {
+			return nil, preErr
 		}
+	}
 
-		if tagCandidatesFound > MaxSuitableTagCandidates || len(tags) == 0 {
-			break
+	var dirty bool
+	if opt.DirtyDetection {
+		if progressErr := p.check(); progressErr != nil {
+			return nil, progressErr
+		}
+		var dirtyErr error
+		if dirty, dirtyErr = isWorktreeDirty(repo, opt.DirtyUntracked); dirtyErr != nil {
+			return nil, fmt.Errorf("failed to detect uncommitted changes: %w", dirtyErr)
 		}
 	}
 
+	version, versionErr := newVersion(defaultVersion, head.hash, candidate, pre, dirty, opt)
+	if versionErr != nil {
+		return nil, versionErr
+	}
+	if constraintErr := checkBranchConstraint(constraint, version.Version, head.branch); constraintErr != nil {
+		return nil, constraintErr
+	}
+	version.Branch = head.branch
+	version.BranchTip = head.branchTip
+	version.Detached = head.detached
+	version.HeadRef = head.ref
+
+	return version, nil
+}
+
+// resolveTagPrefix resolves the tag prefix from the module directory, if set, or returns the configured tag prefix.
+func resolveTagPrefix(repo *git.Repository, opt *Options) (string, error) {
+	if opt.ModuleDir == "" {
+		return opt.TagPrefix, nil
+	}
+
+	wt, wtErr := repo.Worktree()
+	if wtErr != nil {
+		return "", newError(ErrInvalidModule, wtErr, "failed to get worktree to derive tag prefix from module directory")
+	}
+	var tagPrefix string
+	var prefixErr error
+	if isOSFilesystem(wt.Filesystem) {
+		tagPrefix, prefixErr = moduleTagPrefix(wt.Filesystem.Root(), opt.ModuleDir)
+	} else {
+		tagPrefix, prefixErr = worktreeModuleTagPrefix(wt.Filesystem, opt.ModuleDir)
+	}
+	if prefixErr != nil {
+		return "", newError(ErrInvalidModule, prefixErr, "failed to derive tag prefix from module directory")
+	}
+
+	return tagPrefix, nil
+}
+
+// newVersion creates a new version for the given commit hash from the given tag candidate or the default version if
+// the candidate is nil.
+// If the worktree is dirty, the configured dirty mark is appended as build metadata.
+func newVersion(
+	defaultVersion string,
+	commitHash plumbing.Hash,
+	candidate *tagCandidate,
+	pre *prerelease,
+	dirty bool,
+	opt *Options,
+) (*Version, error) {
 	// Use the given version by default or...
-	semVersion, semVerErr := semver.NewVersion(defaultVersion)
-	version := &Version{Version: semVersion}
+	semVersion, semVerErr := opt.versionScheme().Parse(defaultVersion)
 	if semVerErr != nil {
-		return nil, fmt.Errorf("failed to parse default version: %v", semVerErr)
-	}
-	// ...the latest Git tag from the current branch if at least one tag has been found.
-	if len(tagCandidates) != 0 {
-		semVersion, semVerErr = semver.NewVersion(tagCandidates[0].ref.Name().Short())
-		version = &Version{Version: semVersion}
-		if semVerErr != nil {
-			return nil, fmt.Errorf("failed to parse version from Git tag %s: %v",
-				tagCandidates[0].ref.Name().Short(), semVerErr)
-		}
+		return nil, newError(ErrInvalidDefaultVersion, semVerErr, "failed to parse default version %q", defaultVersion)
 	}
-	// Add additional version information if the latest commit of the current branch is not the found tag.
-	if len(tagCandidates) != 0 && tagCandidates[0].distance > 0 {
-		// If not included in the tag already, append metadata consisting of the amount of commit(s) ahead and the
-		// shortened commit hash (8 digits) of the latest commit.
-		buildMetaData := fmt.Sprintf("%s.%s",
-			strconv.Itoa(tagCandidates[0].distance), currentBranchRef.Hash().String()[:8])
-		if version.Metadata() != "" {
-			metadataVersion, mdvErr := version.SetMetadata(fmt.Sprintf("%s-%s", version.Metadata(), buildMetaData))
-			if mdvErr != nil {
-				return nil, fmt.Errorf("failed to set version metadata: %v", mdvErr)
+	version := &Version{Version: semVersion, CommitHash: commitHash, Dirty: dirty, scheme: opt.versionScheme()}
+
+	switch {
+	case pre != nil:
+		// ...an ordered prerelease version of the next version for the channel if HEAD is not pointing to the found tag.
+		if candidate != nil {
+			version.CommitsAhead = candidate.distance
+			version.LatestVersionTag = candidate.ref
+			version.Signer = candidate.signer
+			version.tagVersion = candidate.version
+			semVersion = candidate.version
+		}
+		prereleaseVersion, preErr := pre.version(semVersion, version.scheme)
+		if preErr != nil {
+			return nil, preErr
+		}
+		version.Version = prereleaseVersion
+		version.Channel = pre.channel
+		if opt.HashAbbrevLength > 0 {
+			if mdErr := version.appendMetadata(abbrevHash(commitHash, opt.HashAbbrevLength)); mdErr != nil {
+				return nil, mdErr
 			}
-			version.Version = &metadataVersion
-		} else {
-			metadataVersion, mdvErr := version.SetMetadata(buildMetaData)
-			if mdvErr != nil {
-				return nil, fmt.Errorf("failed to set version metadata: %v", mdvErr)
+		}
+	case candidate == nil:
+		// ...append the shortened commit hash if explicitly requested when no tag has been found.
+		if opt.Always && opt.HashAbbrevLength > 0 {
+			if mdErr := version.appendMetadata(abbrevHash(commitHash, opt.HashAbbrevLength)); mdErr != nil {
+				return nil, mdErr
 			}
-			version.Version = &metadataVersion
 		}
+	default:
+		// ...the latest Git tag from the history of HEAD if at least one tag has been found.
+		version.Version = candidate.version
+		version.CommitsAhead = candidate.distance
+		version.LatestVersionTag = candidate.ref
+		version.Signer = candidate.signer
+		version.tagVersion = candidate.version
 
-		version.CommitsAhead = tagCandidates[0].distance
-		version.CommitHash = currentBranchRef.Hash()
-		version.LatestVersionTag = tagCandidates[0].ref
+		// Add additional version information if the commit HEAD is pointing to is not the found tag or if explicitly
+		// requested.
+		if opt.HashAbbrevLength > 0 && (candidate.distance > 0 || opt.Long) {
+			// Append metadata consisting of the amount of commit(s) ahead and the shortened commit hash of HEAD.
+			buildMetaData := fmt.Sprintf("%s.%s", strconv.Itoa(candidate.distance), abbrevHash(commitHash, opt.HashAbbrevLength))
+			if mdErr := version.appendMetadata(buildMetaData); mdErr != nil {
+				return nil, mdErr
+			}
+		}
+	}
+
+	if dirty && opt.DirtyMark != "" {
+		if mdErr := version.appendMetadata(opt.DirtyMark); mdErr != nil {
+			return nil, mdErr
+		}
 	}
 
 	return version, nil
 }
+
+// String returns the version in the format of the version scheme it has been derived with.
+func (v Version) String() string {
+	if v.scheme == nil {
+		return v.Version.String()
+	}
+
+	return v.scheme.Format(v.Version)
+}
+
+// appendMetadata appends the given build metadata to the version.
+// If the version already includes build metadata, the given metadata is joined with a hyphen.
+func (v *Version) appendMetadata(metadata string) error {
+	if v.Metadata() != "" {
+		metadata = fmt.Sprintf("%s-%s", v.Metadata(), metadata)
+	}
+	metadataVersion, mdvErr := v.SetMetadata(metadata)
+	if mdvErr != nil {
+		return fmt.Errorf("failed to set version metadata: %w", mdvErr)
+	}
+	v.Version = &metadataVersion
+
+	return nil
+}
+
+// abbrevHash shortens the given hash to the given length that is clamped to the range supported by Git.
+func abbrevHash(hash plumbing.Hash, length int) string {
+	switch {
+	case length < MinHashAbbrevLength:
+		length = MinHashAbbrevLength
+	case length > MaxHashAbbrevLength:
+		length = MaxHashAbbrevLength
+	}
+
+	return hash.String()[:length]
+}
@@ -0,0 +1,545 @@
+// Copyright (c) 2020-present Sven Greb <development@svengreb.de>
+// This source code is licensed under the MIT license found in the LICENSE file.
+
+package git_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+
+	glGit "github.com/svengreb/golib/pkg/vcs/git"
+)
+
+const testDefaultVersion = "0.0.0"
+
+// testRepository is a Git repository in a temporary directory for testing purposes.
+type testRepository struct {
+	commits int
+	path    string
+	repo    *git.Repository
+	t       *testing.T
+}
+
+func newTestRepository(t *testing.T) *testRepository {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		assert.FailNow(t, "failed to initialize repository", "path: %q\nerror: %v", dir, err)
+	}
+
+	return &testRepository{path: dir, repo: repo, t: t}
+}
+
+// signature returns a signature with a time that is incremented for each commit to ensure a stable commit order.
+func (tr *testRepository) signature() *object.Signature {
+	return &object.Signature{
+		Name:  "Sven Greb",
+		Email: "development@svengreb.de",
+		When:  time.Date(2020, 11, 21, 12, 0, 0, 0, time.UTC).Add(time.Duration(tr.commits) * time.Minute),
+	}
+}
+
+// commit writes a file and commits it with the given message.
+// When no parents are given, the commit HEAD is pointing to is used as parent.
+// Note that the branch HEAD is pointing to is always moved to the new commit.
+func (tr *testRepository) commit(msg string, parents ...plumbing.Hash) plumbing.Hash {
+	tr.t.Helper()
+	wt, err := tr.repo.Worktree()
+	if err != nil {
+		assert.FailNow(tr.t, "failed to get worktree", "error: %v", err)
+	}
+
+	tr.commits++
+	name := fmt.Sprintf("file-%d", tr.commits)
+	if err = os.WriteFile(filepath.Join(tr.path, name), []byte(msg), 0o600); err != nil {
+		assert.FailNow(tr.t, "failed to write file", "file: %q\nerror: %v", name, err)
+	}
+	if _, err = wt.Add(name); err != nil {
+		assert.FailNow(tr.t, "failed to add file", "file: %q\nerror: %v", name, err)
+	}
+	hash, err := wt.Commit(msg, &git.CommitOptions{Author: tr.signature(), Parents: parents})
+	if err != nil {
+		assert.FailNow(tr.t, "failed to commit", "message: %q\nerror: %v", msg, err)
+	}
+
+	return hash
+}
+
+// tag creates an annotated tag, or a lightweight tag if the message is empty.
+func (tr *testRepository) tag(name string, hash plumbing.Hash, msg string) {
+	tr.t.Helper()
+	var opts *git.CreateTagOptions
+	if msg != "" {
+		opts = &git.CreateTagOptions{Tagger: tr.signature(), Message: msg}
+	}
+	if _, err := tr.repo.CreateTag(name, hash, opts); err != nil {
+		assert.FailNow(tr.t, "failed to create tag", "tag: %q\nerror: %v", name, err)
+	}
+}
+
+// signedTag creates an annotated tag that is signed with the given OpenPGP entity.
+func (tr *testRepository) signedTag(name string, hash plumbing.Hash, entity *openpgp.Entity) {
+	tr.t.Helper()
+	opts := &git.CreateTagOptions{Tagger: tr.signature(), Message: name, SignKey: entity}
+	if _, err := tr.repo.CreateTag(name, hash, opts); err != nil {
+		assert.FailNow(tr.t, "failed to create signed tag", "tag: %q\nerror: %v", name, err)
+	}
+}
+
+// countdownContext is a context that is canceled after its error has been checked a given amount of times.
+type countdownContext struct {
+	context.Context
+	remaining int
+}
+
+func (c *countdownContext) Err() error {
+	if c.remaining <= 0 {
+		return context.DeadlineExceeded
+	}
+	c.remaining--
+	return nil
+}
+
+func TestDeriveVersion(t *testing.T) {
+	tr := newTestRepository(t)
+	first := tr.commit("first")
+	tr.tag("v1.0.0", first, "v1.0.0")
+	tr.commit("second")
+	head := tr.commit("third")
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("1.0.0+2.%s", head.String()[:8]), version.String())
+	assert.Equal(t, 2, version.CommitsAhead)
+	assert.Equal(t, head, version.CommitHash)
+	assert.Equal(t, "v1.0.0", version.LatestVersionTag.Name().Short())
+}
+
+func TestDeriveVersion_ExactTag(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.commit("first")
+	head := tr.commit("second")
+	tr.tag("v1.2.3", head, "v1.2.3")
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3", version.String())
+	assert.Equal(t, 0, version.CommitsAhead)
+}
+
+func TestDeriveVersion_DefaultVersion(t *testing.T) {
+	tr := newTestRepository(t)
+	head := tr.commit("first")
+	tr.tag("v1.0.0", head, "")
+	tr.tag("no-version", head, "no-version")
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testDefaultVersion, version.String())
+	assert.Nil(t, version.LatestVersionTag)
+}
+
+func TestDeriveVersion_FailWithEmptyDefaultVersion(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.commit("first")
+
+	version, err := glGit.DeriveVersion("", tr.path)
+
+	assert.Nil(t, version)
+	assert.True(t, errors.Is(err, glGit.ErrInvalidDefaultVersion))
+}
+
+func TestDeriveVersion_Options(t *testing.T) {
+	tr := newTestRepository(t)
+	first := tr.commit("first")
+	tr.tag("v1.0.0", first, "v1.0.0")
+	second := tr.commit("second")
+	tr.tag("v1.1.0-rc.1", second, "v1.1.0-rc.1")
+	third := tr.commit("third")
+	tr.tag("v1.1.0", third, "")
+	head := tr.commit("fourth")
+
+	testCases := []struct {
+		name     string
+		opts     []glGit.Option
+		expected string
+	}{
+		{"default", nil, fmt.Sprintf("1.1.0-rc.1+2.%s", head.String()[:8])},
+		{"tags", []glGit.Option{glGit.WithTags(true)}, fmt.Sprintf("1.1.0+1.%s", head.String()[:8])},
+		{"match", []glGit.Option{glGit.WithMatchPatterns("v1.0.*")}, fmt.Sprintf("1.0.0+3.%s", head.String()[:8])},
+		{"exclude", []glGit.Option{glGit.WithExcludePatterns("*-rc.*")}, fmt.Sprintf("1.0.0+3.%s", head.String()[:8])},
+		{"abbrev", []glGit.Option{glGit.WithHashAbbrevLength(12)}, fmt.Sprintf("1.1.0-rc.1+2.%s", head.String()[:12])},
+		{"abbrev zero", []glGit.Option{glGit.WithHashAbbrevLength(0)}, "1.1.0-rc.1"},
+		{"candidates zero", []glGit.Option{glGit.WithCandidates(0)}, testDefaultVersion},
+		{"always", []glGit.Option{glGit.WithCandidates(0), glGit.WithAlways(true)}, fmt.Sprintf("0.0.0+%s", head.String()[:8])},
+	}
+
+	for _, tc := range testCases {
+		version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, tc.opts...)
+
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, version.String(), tc.name)
+	}
+}
+
+func TestDeriveVersion_Long(t *testing.T) {
+	tr := newTestRepository(t)
+	head := tr.commit("first")
+	tr.tag("v1.0.0", head, "v1.0.0")
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithLong(true))
+
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("1.0.0+0.%s", head.String()[:8]), version.String())
+}
+
+func TestDeriveVersion_Dirty(t *testing.T) {
+	tr := newTestRepository(t)
+	head := tr.commit("first")
+	tr.tag("v1.0.0", head, "v1.0.0")
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithDirtyMark(glGit.DefaultDirtyMark))
+	assert.NoError(t, err)
+	assert.False(t, version.Dirty)
+	assert.Equal(t, "1.0.0", version.String())
+
+	if err = os.WriteFile(filepath.Join(tr.path, "untracked"), []byte("untracked"), 0o600); err != nil {
+		assert.FailNow(t, "failed to write untracked file", "error: %v", err)
+	}
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithDirtyMark(glGit.DefaultDirtyMark))
+	assert.NoError(t, err)
+	assert.False(t, version.Dirty)
+
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path,
+		glGit.WithDirtyMark(glGit.DefaultDirtyMark), glGit.WithDirtyUntracked(true))
+	assert.NoError(t, err)
+	assert.True(t, version.Dirty)
+	assert.Equal(t, "1.0.0+dirty", version.String())
+
+	if err = os.WriteFile(filepath.Join(tr.path, "file-1"), []byte("modified"), 0o600); err != nil {
+		assert.FailNow(t, "failed to modify file", "error: %v", err)
+	}
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithLong(true), glGit.WithDirtyMark("modified"))
+	assert.NoError(t, err)
+	assert.True(t, version.Dirty)
+	assert.Equal(t, fmt.Sprintf("1.0.0+0.%s-modified", head.String()[:8]), version.String())
+}
+
+func TestDeriveVersion_Head(t *testing.T) {
+	tr := newTestRepository(t)
+	first := tr.commit("first")
+	tr.tag("v1.0.0", first, "v1.0.0")
+	head := tr.commit("second")
+	if err := tr.repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/another", head)); err != nil {
+		assert.FailNow(t, "failed to create branch", "error: %v", err)
+	}
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path)
+	assert.NoError(t, err)
+	assert.Equal(t, "master", version.Branch)
+	assert.Equal(t, plumbing.Master, version.HeadRef)
+	assert.True(t, version.BranchTip)
+	assert.False(t, version.Detached)
+	expected := version.String()
+
+	// Detach HEAD from the branch like most CI checkouts.
+	if err = tr.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, head)); err != nil {
+		assert.FailNow(t, "failed to detach HEAD", "error: %v", err)
+	}
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, version.String())
+	assert.Empty(t, version.Branch)
+	assert.Equal(t, plumbing.HEAD, version.HeadRef)
+	assert.True(t, version.BranchTip)
+	assert.True(t, version.Detached)
+
+	if err = tr.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, first)); err != nil {
+		assert.FailNow(t, "failed to detach HEAD", "error: %v", err)
+	}
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.String())
+	assert.Equal(t, first, version.CommitHash)
+	assert.False(t, version.BranchTip)
+	assert.True(t, version.Detached)
+}
+
+func TestDeriveVersion_TraversalMode(t *testing.T) {
+	tr := newTestRepository(t)
+	first := tr.commit("first")
+	tr.tag("v1.0.0", first, "v1.0.0")
+	mainline := tr.commit("mainline", first)
+	feature := tr.commit("feature", first)
+	tr.tag("v1.1.0", feature, "v1.1.0")
+	mainline = tr.commit("mainline", mainline)
+	head := tr.commit("merge", mainline, feature)
+
+	// The expected values are the same like the output of the Git "describe" command.
+	testCases := []struct {
+		mode     glGit.TraversalMode
+		expected string
+	}{
+		{glGit.TraversalCommitterTime, fmt.Sprintf("1.1.0+2.%s", head.String()[:8])},
+		{glGit.TraversalGraph, fmt.Sprintf("1.1.0+3.%s", head.String()[:8])},
+		{glGit.TraversalFirstParent, fmt.Sprintf("1.0.0+3.%s", head.String()[:8])},
+	}
+
+	for _, tc := range testCases {
+		version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithTraversalMode(tc.mode))
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, version.String())
+	}
+
+	// Further candidates are ignored when the traversal stops at the first candidate in committer time order.
+	for _, candidates := range []int{1, 2, glGit.MaxSuitableTagCandidates} {
+		version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithCandidates(candidates))
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("1.1.0+2.%s", head.String()[:8]), version.String(), "candidates: %d", candidates)
+	}
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path,
+		glGit.WithCandidates(2),
+		glGit.WithTraversalMode(glGit.TraversalGraph),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("1.1.0+3.%s", head.String()[:8]), version.String())
+}
+
+func TestDeriveVersion_TagPrefix(t *testing.T) {
+	tr := newTestRepository(t)
+	modules := map[string]string{
+		"tools":  "module example.com/repo/tools\n",
+		"mod/v2": "module \"example.com/repo/mod/v2\" // Major version subdirectory.\n",
+	}
+	for dir, content := range modules {
+		if err := os.MkdirAll(filepath.Join(tr.path, dir), 0o700); err != nil {
+			assert.FailNow(t, "failed to create module directory", "dir: %q\nerror: %v", dir, err)
+		}
+		if err := os.WriteFile(filepath.Join(tr.path, dir, "go.mod"), []byte(content), 0o600); err != nil {
+			assert.FailNow(t, "failed to write Go module file", "dir: %q\nerror: %v", dir, err)
+		}
+	}
+	head := tr.commit("first")
+	tr.tag("v1.0.0", head, "v1.0.0")
+	tr.tag("tools/v0.2.0", head, "tools/v0.2.0")
+	tr.tag("mod/v2.1.0", head, "mod/v2.1.0")
+
+	testCases := []struct {
+		opts     []glGit.Option
+		expected string
+		tag      string
+	}{
+		{nil, "1.0.0", "v1.0.0"},
+		{[]glGit.Option{glGit.WithTagPrefix("tools/")}, "0.2.0", "tools/v0.2.0"},
+		{[]glGit.Option{glGit.WithModuleDir(filepath.Join(tr.path, "tools"))}, "0.2.0", "tools/v0.2.0"},
+		{[]glGit.Option{glGit.WithModuleDir(filepath.Join(tr.path, "mod", "v2"))}, "2.1.0", "mod/v2.1.0"},
+	}
+
+	for _, tc := range testCases {
+		version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, tc.opts...)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, version.String())
+		assert.Equal(t, tc.tag, version.LatestVersionTag.Name().Short())
+	}
+
+	_, err := glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithModuleDir(t.TempDir()))
+	assert.Error(t, err)
+}
+
+func TestDeriveVersion_VerifyKeyRing(t *testing.T) {
+	trusted, untrusted := newTestEntity(t), newTestEntity(t)
+	tr := newTestRepository(t)
+	signed := tr.commit("feat: initial feature")
+	tr.signedTag("v1.0.0", signed, trusted)
+	tr.tag("v1.1.0", tr.commit("feat: add feature"), "v1.1.0")
+	tr.signedTag("v1.2.0", tr.commit("feat: add another feature"), untrusted)
+	head := tr.commit("fix: fix bug")
+	tr.tag("v1.3.0", head, "")
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithTags(true))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0", version.String())
+	assert.Nil(t, version.Signer)
+
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithTags(true),
+		glGit.WithVerifyKeyRing(armoredKeyRing(t, trusted)))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.0+3."+head.String()[:8], version.String())
+	if assert.NotNil(t, version.Signer) {
+		assert.Equal(t, trusted.PrimaryKey.KeyId, version.Signer.PrimaryKey.KeyId)
+	}
+
+	version, err = glGit.DeriveVersion(testDefaultVersion, tr.path,
+		glGit.WithVerifyKeyRing(armoredKeyRing(t, trusted, untrusted)))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.0+1."+head.String()[:8], version.String())
+	if assert.NotNil(t, version.Signer) {
+		assert.Equal(t, untrusted.PrimaryKey.KeyId, version.Signer.PrimaryKey.KeyId)
+	}
+
+	_, err = glGit.DeriveVersion(testDefaultVersion, tr.path, glGit.WithVerifyKeyRing("invalid"))
+	assert.Error(t, err)
+}
+
+func TestDeriveVersionContext(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.tag("v1.0.0", tr.commit("feat: initial feature"), "v1.0.0")
+	tr.tag("v1.1.0", tr.commit("feat: add feature"), "v1.1.0")
+	tr.commit("fix: fix bug")
+	head := tr.commit("fix: fix another bug")
+
+	version, err := glGit.DeriveVersionContext(context.Background(), testDefaultVersion, tr.path)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.0+2."+head.String()[:8], version.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = glGit.DeriveVersionContext(ctx, testDefaultVersion, tr.path)
+	var canceledErr *glGit.CanceledError
+	if assert.True(t, errors.As(err, &canceledErr)) {
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Equal(t, 0, canceledErr.Tags)
+		assert.Equal(t, 0, canceledErr.Commits)
+	}
+
+	for _, mode := range []glGit.TraversalMode{glGit.TraversalCommitterTime, glGit.TraversalGraph} {
+		// The context is checked once before the derivation, once for each of the two tags and once for each commit.
+		_, err = glGit.DeriveVersionContext(&countdownContext{Context: context.Background(), remaining: 4},
+			testDefaultVersion, tr.path, glGit.WithTraversalMode(mode))
+		if assert.True(t, errors.As(err, &canceledErr), "traversal mode: %d", mode) {
+			assert.True(t, errors.Is(err, context.DeadlineExceeded))
+			assert.Equal(t, 2, canceledErr.Tags)
+			assert.Equal(t, 1, canceledErr.Commits)
+			assert.Equal(t, head, canceledErr.LastCommit)
+		}
+	}
+}
+
+func TestDeriveVersionFromRepository(t *testing.T) {
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	if err != nil {
+		assert.FailNow(t, "failed to initialize in-memory repository", "error: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		assert.FailNow(t, "failed to get worktree", "error: %v", err)
+	}
+	tr := &testRepository{repo: repo, t: t}
+	commit := func(name, content, msg string) plumbing.Hash {
+		if writeErr := util.WriteFile(fs, name, []byte(content), 0o600); writeErr != nil {
+			assert.FailNow(t, "failed to write file", "file: %q\nerror: %v", name, writeErr)
+		}
+		if _, addErr := wt.Add(name); addErr != nil {
+			assert.FailNow(t, "failed to add file", "file: %q\nerror: %v", name, addErr)
+		}
+		tr.commits++
+		hash, commitErr := wt.Commit(msg, &git.CommitOptions{Author: tr.signature()})
+		if commitErr != nil {
+			assert.FailNow(t, "failed to commit", "message: %q\nerror: %v", msg, commitErr)
+		}
+		return hash
+	}
+
+	tr.tag("v1.0.0", commit("README.md", "golib", "feat: initial feature"), "v1.0.0")
+	tr.tag("mod/v2.1.0", commit("mod/v2/go.mod", "module example.com/repo/mod/v2\n", "feat: add module"), "mod/v2.1.0")
+	head := commit("mod/v2/main.go", "package mod", "fix: fix bug")
+
+	version, err := glGit.DeriveVersionFromRepository(repo, testDefaultVersion)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.0+2."+head.String()[:8], version.String())
+
+	version, err = glGit.DeriveVersionFromRepository(repo, testDefaultVersion, glGit.WithModuleDir("mod/v2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2.1.0+1."+head.String()[:8], version.String())
+
+	_, err = glGit.DeriveVersionFromRepository(repo, testDefaultVersion, glGit.WithModuleDir("../mod"))
+	assert.Error(t, err)
+
+	if err = util.WriteFile(fs, "README.md", []byte("changed"), 0o600); err != nil {
+		assert.FailNow(t, "failed to write file", "error: %v", err)
+	}
+	version, err = glGit.DeriveVersionFromRepository(repo, testDefaultVersion, glGit.WithDirtyMark(glGit.DefaultDirtyMark))
+	assert.NoError(t, err)
+	assert.True(t, version.Dirty)
+	assert.Equal(t, "1.0.0+2."+head.String()[:8]+"-dirty", version.String())
+
+	bare, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		assert.FailNow(t, "failed to initialize bare in-memory repository", "error: %v", err)
+	}
+	_, err = glGit.DeriveVersionFromRepository(bare, testDefaultVersion)
+	assert.Error(t, err)
+
+	_, err = glGit.DeriveVersionFromRepository(nil, testDefaultVersion)
+	assert.Error(t, err)
+}
+
+func TestDeriveVersion_DetectDotGit(t *testing.T) {
+	tr := newTestRepository(t)
+	tr.tag("v1.0.0", tr.commit("feat: initial feature"), "v1.0.0")
+	subDir := filepath.Join(tr.path, "a", "b")
+	if err := os.MkdirAll(subDir, 0o700); err != nil {
+		assert.FailNow(t, "failed to create directory", "path: %q\nerror: %v", subDir, err)
+	}
+
+	_, err := glGit.DeriveVersion(testDefaultVersion, subDir)
+	assert.Error(t, err)
+
+	version, err := glGit.DeriveVersion(testDefaultVersion, subDir, glGit.WithDetectDotGit(true))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.0", version.String())
+
+	_, err = glGit.DeriveVersion(testDefaultVersion, subDir, glGit.WithDetectDotGit(true), glGit.WithCeilingDirs(tr.path))
+	assert.Error(t, err)
+	_, err = glGit.DeriveVersion(testDefaultVersion, subDir, glGit.WithDetectDotGit(true),
+		glGit.WithCeilingDirs(filepath.Dir(tr.path)))
+	assert.NoError(t, err)
+
+	// Simulate a linked worktree created through the Git "worktree add" command whose ".git" file points to its Git
+	// directory within the Git directory of the main worktree.
+	head := tr.commit("fix: fix bug")
+	linkedDir := filepath.Join(t.TempDir(), "linked")
+	linkedGitDir := filepath.Join(tr.path, git.GitDirName, "worktrees", "linked")
+	files := map[string]string{
+		filepath.Join(linkedDir, git.GitDirName): "gitdir: " + linkedGitDir + "\n",
+		filepath.Join(linkedDir, "sub", "file"):  "",
+		filepath.Join(linkedGitDir, "HEAD"):      head.String() + "\n",
+		filepath.Join(linkedGitDir, "commondir"): "../..\n",
+		filepath.Join(linkedGitDir, "gitdir"):    filepath.Join(linkedDir, git.GitDirName) + "\n",
+	}
+	for name, content := range files {
+		if err = os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+			assert.FailNow(t, "failed to create directory", "path: %q\nerror: %v", filepath.Dir(name), err)
+		}
+		if err = os.WriteFile(name, []byte(content), 0o600); err != nil {
+			assert.FailNow(t, "failed to write file", "file: %q\nerror: %v", name, err)
+		}
+	}
+
+	version, err = glGit.DeriveVersion(testDefaultVersion, filepath.Join(linkedDir, "sub", "file"),
+		glGit.WithDetectDotGit(true))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.0+1."+head.String()[:8], version.String())
+	assert.True(t, version.Detached)
+}